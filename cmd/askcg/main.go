@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"cg-mentions-bot/internal/cg"
+	"cg-mentions-bot/internal/config"
 
 	mcpclient "github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -23,14 +24,20 @@ func main() {
 	flag.BoolVar(&list, "list", false, "list available tools instead of asking a question")
 	flag.StringVar(&tool, "tool", "", "call a specific tool (overrides -q)")
 	flag.StringVar(&argsJSON, "args", "", "JSON object string for tool arguments (used with -tool)")
+	configPath := flag.String("config", os.Getenv(config.EnvFile), "YAML config file; environment variables override it")
 	flag.Parse()
 
-	mcpCmd := os.Getenv("MCP_CMD")
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERR:", err)
+		os.Exit(1)
+	}
+	mcpCmd := cfg.Legacy.MCPCmd
 	if mcpCmd == "" {
-		fmt.Fprintln(os.Stderr, "MCP_CMD env required (path to MCP stdio executable)")
+		fmt.Fprintln(os.Stderr, "MCP_CMD env (or legacy.mcp_cmd) required (path to MCP stdio executable)")
 		os.Exit(1)
 	}
-	mcpTool := getEnv("MCP_TOOL", "coingecko.answer")
+	mcpTool := cfg.Legacy.MCPTool
 
 	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second)
 	defer cancel()
@@ -60,13 +67,6 @@ func main() {
 	fmt.Println(ans)
 }
 
-func getEnv(k, def string) string {
-	if v, ok := os.LookupEnv(k); ok {
-		return v
-	}
-	return def
-}
-
 func listTools(ctx context.Context, mcpCmd string) error {
 	c, err := mcpclient.NewStdioMCPClient(mcpCmd, nil)
 	if err != nil {
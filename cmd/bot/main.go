@@ -1,57 +1,339 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"cg-mentions-bot/internal/agent"
 	"cg-mentions-bot/internal/cg"
+	"cg-mentions-bot/internal/classify"
+	"cg-mentions-bot/internal/config"
+	"cg-mentions-bot/internal/entities"
 	"cg-mentions-bot/internal/handlers"
+	"cg-mentions-bot/internal/health"
 	"cg-mentions-bot/internal/httpserver"
+	"cg-mentions-bot/internal/jobs"
+	"cg-mentions-bot/internal/llm"
+	"cg-mentions-bot/internal/policy"
+	"cg-mentions-bot/internal/poller"
+	"cg-mentions-bot/internal/retry"
+	"cg-mentions-bot/internal/store"
+	"cg-mentions-bot/internal/tracing"
 	"cg-mentions-bot/internal/twitter"
+	"cg-mentions-bot/internal/webhook"
 )
 
 func main() {
-	port := getEnv("PORT", "8080")
-	webhookSecret := os.Getenv("WEBHOOK_SECRET")
-	agentCmd := os.Getenv("AGENT_CMD")
-	mcpCmd := os.Getenv("MCP_CMD")
-	mcpTool := getEnv("MCP_TOOL", "coingecko.answer")
-	bearerToken := os.Getenv("X_BEARER_TOKEN")
-	baseURL := getEnv("X_BASE", "https://api.twitter.com/2")
+	configPath := flag.String("config", os.Getenv(config.EnvFile), "YAML config file; environment variables override it")
+	flag.Parse()
 
-	// WEBHOOK_SECRET is optional; if empty, the handler won't enforce it.
-	if agentCmd == "" {
-		if mcpCmd == "" {
-			log.Fatal("MCP_CMD is required (path to CoinGecko MCP server binary)")
-		}
-		if bearerToken == "" {
-			log.Fatal("X_BEARER_TOKEN is required (user-context token with tweet.write)")
-		}
+	// WEBHOOK_SECRET and WEBHOOK_HMAC_SECRETS are optional; if empty, the handler won't enforce them.
+	cfg, err := config.Load(*configPath)
+	if err == nil {
+		err = cfg.ValidateBot()
+	}
+	if err != nil {
+		log.Fatal(err)
 	}
+	port := cfg.Server.Port
 
-	ask := cg.NewAsker(mcpCmd, mcpTool)
-	reply := twitter.NewPoster(baseURL, bearerToken)
+	// ctx is cancelled on SIGINT/SIGTERM and stops the poller and other background loops.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, "cg-mentions-bot")
+	if err != nil {
+		log.Fatalf("tracing: %v", err)
+	}
 
-	handler := handlers.MentionsHandler{Secret: webhookSecret}
-	if agentCmd != "" {
-		handler.AgentRun = agent.NewRunner(agentCmd)
+	ask := cg.NewAsker(cfg.Legacy.MCPCmd, cfg.Legacy.MCPTool)
+	reply := twitter.NewPoster(cfg.X.Base, cfg.X.BearerToken, cfg.X.PosterAuth())
+
+	st, err := store.Open(cfg.Server.StorePath)
+	if err != nil {
+		log.Fatalf("store: %v", err)
+	}
+	defer st.Close()
+
+	handler := handlers.MentionsHandler{Secret: cfg.Webhook.Secret, AdminToken: cfg.Server.AdminToken, Store: st, Settings: handlers.NewLiveSettings(settingsOf(cfg.Policy))}
+	if len(cfg.Webhook.HMACSecrets) > 0 {
+		handler.Verifier = webhook.NewVerifier(cfg.Webhook.HMACSecrets, cfg.Webhook.MaxSkew)
+	}
+	if cfg.Agent.Enabled() {
+		run, err := newAgentRunner(ctx, cfg)
+		if err != nil {
+			log.Fatalf("agent: %v", err)
+		}
+		handler.AgentRun = run
+		// Canned replies, and answers with REPLY_POSTER=bot, bypass the agent
+		// and post through xmcp directly, or with the X API credentials.
+		if cfg.Agent.XMCPHTTP != "" {
+			handler.Reply = twitter.NewMCPPoster(cfg.Agent.XMCPHTTP)
+		} else if cfg.ValidatePoster() == nil {
+			handler.Reply = reply
+		}
 	} else {
 		handler.Ask = ask
 		handler.Reply = reply
 	}
 
-	srv := httpserver.NewServer(port, handler)
-	log.Printf("cg-mentions-bot listening on :%s", port)
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("server error: %v", err)
+	if cfg.Classify.Enabled {
+		c, err := newClassifier(cfg)
+		if err != nil {
+			log.Fatalf("classifier: %v", err)
+		}
+		handler.Classifier = c
+	}
+
+	if cfg.X.BearerToken != "" && cfg.X.ThreadContext {
+		handler.Thread = twitter.NewThreadFetcher(cfg.X.Base, cfg.X.BearerToken, cfg.X.ThreadContextDepth)
+	}
+
+	if cfg.Entities.Enabled {
+		catalog := entities.NewCatalog(cfg.Entities.APIBase, cfg.Entities.APIKey, cfg.Entities.Cache, cfg.Entities.TTL)
+		go catalog.Run(ctx, log.Printf)
+		handler.Entities = catalog
+	}
+
+	// Validate has already parsed the mode.
+	staleMode, _ := policy.ParseStaleMode(cfg.Stale.Mode)
+	handler.Staleness = &policy.Staleness{Mode: staleMode, After: cfg.Stale.After, MaxAge: cfg.Stale.MaxAge}
+
+	authors := policy.NewAuthors(nil, nil)
+	authors.SelfID = cfg.X.UserID
+	authors.SelfUsername = cfg.X.Handle
+	configureAuthors(authors, cfg.Policy.Authors)
+	handler.Authors = authors
+
+	// Only the policy section is applied on reload; the rest needs a restart.
+	go config.Watch(ctx, *configPath, 2*time.Second, log.Printf, func(next *config.Config) {
+		handler.Settings.Store(settingsOf(next.Policy))
+		configureAuthors(authors, next.Policy.Authors)
+		log.Printf("config: policy applied (posting=%s, reply_poster=%s)", next.Policy.PostingMode, next.Policy.ReplyPoster)
+	})
+
+	queue := jobs.NewQueue(cfg.Server.Workers, cfg.Server.QueueSize, handler.Process)
+	queue.Timeout = cfg.Server.JobTimeout
+	// The queue is stopped with Shutdown below rather than by ctx, so in-flight
+	// mentions can finish.
+	queue.Start(context.Background())
+	handler.Queue = queue
+
+	if pending, err := st.TakePending(); err != nil {
+		log.Printf("store: pending mentions: %v", err)
+	} else if len(pending) > 0 {
+		n, err := handler.Dispatch(ctx, pending)
+		if err != nil {
+			log.Printf("requeue: %v", err)
+			if err := st.SavePending(pending[n:]); err != nil {
+				log.Printf("store: save pending: %v", err)
+			}
+		}
+		log.Printf("requeued %d mentions left over from the last shutdown", n)
+	}
+
+	if cfg.X.PollInterval > 0 {
+		p := &poller.Poller{
+			UserID:   cfg.X.UserID,
+			Interval: cfg.X.PollInterval,
+			Fetch:    twitter.NewMentionsFetcher(cfg.X.Base, cfg.X.BearerToken),
+			Submit:   handler.Dispatch,
+			Cursor:   st,
+		}
+		go p.Run(ctx)
+		log.Printf("polling mentions of user %s every %s", cfg.X.UserID, cfg.X.PollInterval)
+	}
+
+	if cfg.Retry.MaxAttempts > 1 {
+		r := &retry.Retrier{
+			Interval:    cfg.Retry.Interval,
+			Backoff:     cfg.Retry.Backoff,
+			MaxBackoff:  cfg.Retry.MaxBackoff,
+			MaxAttempts: cfg.Retry.MaxAttempts,
+			Window:      cfg.Retry.Window,
+			Submit:      handler.Dispatch,
+			Store:       st,
+		}
+		go r.Run(ctx)
+	}
+
+	if retention := cfg.Server.AuditRetention; retention > 0 {
+		go pruneAudit(ctx, st, retention)
+	}
+
+	checks := []health.Check{health.Func("store", true, st.Ping)}
+	if cfg.Agent.Enabled() {
+		if cfg.Agent.Mode == "exec" {
+			checks = append(checks, health.Executable("agent", cfg.Agent.Cmd))
+		}
+		checks = append(checks,
+			health.MCP("coingecko_mcp", cfg.Agent.CGMCPHTTP),
+			health.MCP("x_mcp", cfg.Agent.XMCPHTTP, twitter.PostReplyTool),
+			health.Configured("llm_credentials", map[string]string{"OPENAI_API_KEY": cfg.LLM.APIKey}),
+		)
+	} else {
+		checks = append(checks, health.Executable("coingecko_mcp", cfg.Legacy.MCPCmd))
+	}
+	if handler.Entities != nil {
+		catalog := handler.Entities
+		checks = append(checks, health.Func("coins_catalog", false, func() error {
+			if !catalog.Ready() {
+				return errors.New("coins list not loaded yet")
+			}
+			return nil
+		}))
+	}
+	opts := []httpserver.Option{httpserver.WithReadiness(&health.Checker{
+		Checks:  checks,
+		Timeout: cfg.Server.ReadyTimeout,
+	})}
+	if activityPath := cfg.X.ActivityWebhookPath; activityPath != "" {
+		opts = append(opts, httpserver.WithActivity(activityPath, handlers.ActivityHandler{
+			ConsumerSecret: cfg.X.ConsumerSecret,
+			UserID:         cfg.X.UserID,
+			Handle:         cfg.X.Handle,
+			Mentions:       handler,
+		}))
+		log.Printf("X account activity webhook at %s for @%s", activityPath, cfg.X.Handle)
+	}
+
+	if adminToken := cfg.Server.AdminToken; adminToken != "" {
+		opts = append(opts,
+			httpserver.WithDrafts(adminToken, handlers.DraftsHandler{Store: st, Reply: handler.Reply}),
+			httpserver.WithDeadLetters(adminToken, handlers.DeadLettersHandler{Store: st, Mentions: handler}),
+			httpserver.WithHistory(adminToken, handlers.HistoryHandler{Store: st, Mentions: handler}),
+			httpserver.WithDashboard(adminToken, handlers.DashboardHandler{Mentions: handler}),
+		)
+		log.Printf("dashboard at http://localhost:%s/dashboard/", port)
+	}
+
+	srv := httpserver.NewServer(port, handler, opts...)
+	go func() {
+		log.Printf("cg-mentions-bot listening on :%s (workers=%d queue=%d posting=%s reply_poster=%s)", port, cfg.Server.Workers, cfg.Server.QueueSize, cfg.Policy.PostingMode, cfg.Policy.ReplyPoster)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("server error: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Printf("shutting down: draining in-flight mentions (up to %s)", cfg.Server.ShutdownTimeout)
+	sctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(sctx); err != nil {
+		log.Printf("server shutdown: %v", err)
+	}
+	if left := queue.Shutdown(sctx); len(left) > 0 {
+		if err := st.SavePending(left); err != nil {
+			log.Printf("store: save %d pending mentions: %v", len(left), err)
+		} else {
+			log.Printf("saved %d unfinished mentions for the next start", len(left))
+		}
+	}
+	if err := shutdownTracing(sctx); err != nil {
+		log.Printf("tracing shutdown: %v", err)
+	}
+	log.Printf("shutdown complete")
+}
+
+// pruneAudit drops mention history older than retention, hourly.
+func pruneAudit(ctx context.Context, st *store.Store, retention time.Duration) {
+	t := time.NewTicker(time.Hour)
+	defer t.Stop()
+	for {
+		if n, err := st.PruneAudit(time.Now().Add(-retention)); err != nil {
+			log.Printf("store: prune audit: %v", err)
+		} else if n > 0 {
+			log.Printf("pruned %d mentions from the audit log", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
 	}
 }
 
-func getEnv(key, def string) string {
-	if v, ok := os.LookupEnv(key); ok {
-		return v
+// settingsOf returns the handler settings for the policy section.
+func settingsOf(p config.Policy) handlers.Settings {
+	return handlers.Settings{
+		RequireApproval: p.PostingMode == "approval",
+		BotPosts:        p.ReplyPoster == "bot",
+		Instructions:    p.Prompts.Instructions,
+		AgentTools:      p.AgentTools,
+	}
+}
+
+// configureAuthors applies the author lists and quota.
+func configureAuthors(a *policy.Authors, c config.Authors) {
+	a.Configure(c.Allowlist, c.Blocklist, c.AllowlistOnly, c.RateLimit, c.RateWindow)
+}
+
+// newAgentRunner returns the long-lived in-process agent's Run, or a Runner
+// that spawns agent.cmd per mention (AGENT_MODE=exec) for process isolation.
+func newAgentRunner(ctx context.Context, cfg *config.Config) (agent.Runner, error) {
+	if cfg.Agent.Mode != "inprocess" {
+		return agent.NewRunner(cfg.Agent.Cmd, agentEnv(cfg)), nil
+	}
+	a, err := agent.New(ctx, agent.Options{
+		CGMCPHTTP: cfg.Agent.CGMCPHTTP,
+		XMCPHTTP:  cfg.Agent.XMCPHTTP,
+		APIKey:    cfg.LLM.APIKey,
+		BaseURL:   cfg.LLM.BaseURL,
+		Model:     cfg.LLM.Model,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Discover the tools now so the first mention doesn't wait; Run retries
+	// if the MCP server isn't up yet.
+	go func() {
+		if err := a.Discover(ctx); err != nil {
+			log.Printf("agent: %v (retrying on the first mention)", err)
+		}
+	}()
+	log.Printf("agent running in process (CoinGecko MCP %s, X MCP %s)", cfg.Agent.CGMCPHTTP, cfg.Agent.XMCPHTTP)
+	return a.Run, nil
+}
+
+// agentEnv passes the agent's MCP servers and LLM settings from the config, so
+// values from the config file reach the agent process too.
+func agentEnv(cfg *config.Config) []string {
+	var env []string
+	for k, v := range map[string]string{
+		"CG_MCP_HTTP":     cfg.Agent.CGMCPHTTP,
+		"X_MCP_HTTP":      cfg.Agent.XMCPHTTP,
+		"OPENAI_API_KEY":  cfg.LLM.APIKey,
+		"OPENAI_BASE_URL": cfg.LLM.BaseURL,
+		"OPENAI_MODEL":    cfg.LLM.Model,
+	} {
+		if v != "" {
+			env = append(env, k+"="+v)
+		}
+	}
+	return env
+}
+
+// newClassifier builds the intent classifier from the classify settings.
+func newClassifier(cfg *config.Config) (*classify.Classifier, error) {
+	policy, err := cfg.Classify.BuildPolicy()
+	if err != nil {
+		return nil, err
+	}
+	c := &classify.Classifier{Policy: policy}
+	if cfg.Classify.LLM {
+		model := cfg.Classify.Model
+		if model == "" {
+			model = cfg.LLM.Model
+		}
+		c.LLM = llm.NewChat(cfg.LLM.BaseURL, cfg.LLM.APIKey, model)
 	}
-	return def
+	return c, nil
 }
@@ -2,25 +2,41 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
+	"net/http"
 	"os"
 	"strings"
 	"time"
 
+	"cg-mentions-bot/internal/config"
+	"cg-mentions-bot/internal/metrics"
+	"cg-mentions-bot/internal/tracing"
+
 	mcpclient "github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 func main() {
-	cmd := os.Getenv("CG_MCP_CMD")
+	configPath := flag.String("config", os.Getenv(config.EnvFile), "YAML config file; environment variables override it")
+	flag.Parse()
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cmd := cfg.CGProxy.Cmd
 	if cmd == "" {
-		log.Fatal("CG_MCP_CMD is required (e.g., 'npx' or path to coingecko mcp binary)")
+		log.Fatal("cgproxy.cmd (CG_MCP_CMD) is required (e.g., 'npx' or path to coingecko mcp binary)")
 	}
-	argsEnv := os.Getenv("CG_MCP_ARGS")
 	args := []string{}
-	if strings.TrimSpace(argsEnv) != "" {
-		args = splitArgs(argsEnv)
+	if strings.TrimSpace(cfg.CGProxy.Args) != "" {
+		args = splitArgs(cfg.CGProxy.Args)
+	}
+
+	// Spans are exported in batches as they end; the server runs until killed.
+	if _, err := tracing.Setup(context.Background(), "cgproxy"); err != nil {
+		log.Fatalf("tracing: %v", err)
 	}
 
 	toolMap, err := fetchTools(cmd, args)
@@ -28,7 +44,9 @@ func main() {
 		log.Fatalf("failed to fetch tools from upstream: %v", err)
 	}
 
-	s := server.NewMCPServer("cg-proxy", "0.1.0", server.WithToolCapabilities(true), server.WithLogging())
+	s := server.NewMCPServer("cg-proxy", "0.1.0", server.WithToolCapabilities(true), server.WithLogging(),
+		server.WithToolHandlerMiddleware(metrics.ToolMiddleware("coingecko")),
+		server.WithToolHandlerMiddleware(tracing.ToolMiddleware("coingecko")))
 
 	// Register each upstream tool name and forward calls
 	for _, tool := range toolMap {
@@ -36,16 +54,19 @@ func main() {
 		s.AddTool(t, func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 			res, fwdErr := forwardCall(cmd, args, req.Params.Name, req.GetArguments())
 			if fwdErr != nil {
+				metrics.UpstreamErrors.WithLabelValues("coingecko_mcp").Inc()
 				return mcp.NewToolResultError(fwdErr.Error()), nil
 			}
 			return res, nil
 		})
 	}
 
-	port := getEnv("PORT", "8082")
-	http := server.NewStreamableHTTPServer(s, server.WithEndpointPath("/mcp"), server.WithStateLess(true))
-	log.Printf("cg-proxy MCP server listening on :%s/mcp (forwarding to: %s %s)", port, cmd, strings.Join(args, " "))
-	if err := http.Start(":" + port); err != nil {
+	port := cfg.CGProxy.Port
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", tracing.Handler(server.NewStreamableHTTPServer(s, server.WithEndpointPath("/mcp"), server.WithStateLess(true))))
+	mux.Handle("/metrics", metrics.Handler())
+	log.Printf("cg-proxy MCP server listening on :%s/mcp (forwarding to: %s %s; metrics on /metrics)", port, cmd, strings.Join(args, " "))
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
 }
@@ -91,10 +112,3 @@ func splitArgs(s string) []string {
 	parts := strings.Fields(s)
 	return parts
 }
-
-func getEnv(k, def string) string {
-	if v, ok := os.LookupEnv(k); ok {
-		return v
-	}
-	return def
-}
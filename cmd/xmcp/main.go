@@ -2,11 +2,17 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 
+	"cg-mentions-bot/internal/config"
 	"cg-mentions-bot/internal/handlers"
+	"cg-mentions-bot/internal/metrics"
+	"cg-mentions-bot/internal/tracing"
 	"cg-mentions-bot/internal/twitter"
 
 	"github.com/mark3labs/mcp-go/mcp"
@@ -14,20 +20,31 @@ import (
 )
 
 func main() {
-	baseURL := getEnv("X_BASE", "https://api.twitter.com/2")
-	bearer := os.Getenv("X_BEARER_TOKEN")
-	if bearer == "" {
-		fmt.Fprintln(os.Stderr, "X_BEARER_TOKEN is required for xmcp")
+	configPath := flag.String("config", os.Getenv(config.EnvFile), "YAML config file; environment variables override it")
+	flag.Parse()
+	cfg, err := config.Load(*configPath)
+	if err == nil {
+		err = cfg.ValidatePoster()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "xmcp: %v\n", err)
 		os.Exit(1)
 	}
 
-	post := twitter.NewPoster(baseURL, bearer)
+	// Spans are exported in batches as they end; the server runs until killed.
+	if _, err := tracing.Setup(context.Background(), "xmcp"); err != nil {
+		log.Fatalf("tracing: %v", err)
+	}
+
+	post := twitter.NewPoster(cfg.X.Base, cfg.X.BearerToken, cfg.X.PosterAuth())
 
 	s := server.NewMCPServer(
 		"x-poster",
 		"0.1.0",
 		server.WithToolCapabilities(true),
 		server.WithLogging(),
+		server.WithToolHandlerMiddleware(metrics.ToolMiddleware("x")),
+		server.WithToolHandlerMiddleware(tracing.ToolMiddleware("x")),
 	)
 
 	tool := mcp.Tool{
@@ -52,28 +69,27 @@ func main() {
 		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		if err := post(ctx, handlers.ReplyIn{InReplyTo: inReply, Text: text}); err != nil {
+		id, err := post(ctx, handlers.ReplyIn{InReplyTo: inReply, Text: text})
+		if err != nil {
 			return mcp.NewToolResultError(err.Error()), nil
 		}
-		return mcp.NewToolResultText("ok"), nil
+		// The agent reads tweet_id to report which reply it posted.
+		out, _ := json.Marshal(map[string]string{"status": "ok", "tweet_id": id})
+		return mcp.NewToolResultText(string(out)), nil
 	})
 
-	port := getEnv("PORT", "8081")
-	httpServer := server.NewStreamableHTTPServer(
+	port := cfg.XMCP.Port
+	mcpServer := server.NewStreamableHTTPServer(
 		s,
 		server.WithEndpointPath("/mcp"),
 		server.WithStateLess(true),
 	)
-	log.Printf("x-poster MCP server listening on :%s/mcp", port)
-	if err := httpServer.Start(":" + port); err != nil {
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", tracing.Handler(mcpServer))
+	mux.Handle("/metrics", metrics.Handler())
+	log.Printf("x-poster MCP server listening on :%s/mcp (metrics on /metrics)", port)
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
 		fmt.Fprintf(os.Stderr, "server error: %v\n", err)
 		os.Exit(1)
 	}
 }
-
-func getEnv(k, def string) string {
-	if v, ok := os.LookupEnv(k); ok {
-		return v
-	}
-	return def
-}
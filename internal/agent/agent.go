@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"cg-mentions-bot/internal/tracing"
+	"cg-mentions-bot/internal/types"
+
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+	"github.com/tmc/langchaingo/schema"
+	"github.com/tmc/langchaingo/tools"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultMaxIterations bounds the agent's reasoning steps when
+// Options.MaxIterations is zero.
+const DefaultMaxIterations = 8
+
+// maxObservation bounds the observation kept per step, in runes.
+const maxObservation = 500
+
+// Options configure an Agent.
+type Options struct {
+	// CGMCPHTTP and XMCPHTTP are the CoinGecko and X MCP endpoints,
+	// e.g. http://localhost:8082/mcp and http://localhost:8081/mcp.
+	CGMCPHTTP string
+	XMCPHTTP  string
+	// APIKey, BaseURL and Model select the OpenAI-compatible LLM.
+	APIKey  string
+	BaseURL string
+	Model   string
+	// MaxIterations defaults to DefaultMaxIterations.
+	MaxIterations int
+}
+
+// Agent answers questions with a LangChainGo ReAct agent over the CoinGecko MCP
+// tools and can reply on X through xmcp. It is safe for concurrent use: the MCP
+// clients, the discovered tools and the LLM client are shared by every Run, and
+// only the executor is built per call.
+type Agent struct {
+	cg, x   *mcpHTTP
+	llm     llms.Model
+	maxIter int
+
+	mu      sync.Mutex
+	cgTools []tools.Tool
+}
+
+// New builds an Agent. The CoinGecko tools are discovered on the first Run (and
+// again after a failed discovery), so New succeeds while the MCP servers start.
+// Errors are *Error of kind ErrConfig or ErrLLM.
+func New(ctx context.Context, opts Options) (*Agent, error) {
+	if opts.CGMCPHTTP == "" || opts.XMCPHTTP == "" {
+		return nil, &Error{Kind: ErrConfig, Message: "CoinGecko and X MCP endpoints are required"}
+	}
+	llmOpts := []openai.Option{openai.WithModel(opts.Model), openai.WithToken(opts.APIKey)}
+	if opts.BaseURL != "" {
+		llmOpts = append(llmOpts, openai.WithBaseURL(opts.BaseURL))
+	}
+	model, err := openai.New(llmOpts...)
+	if err != nil {
+		msg := err.Error()
+		if opts.APIKey == "" {
+			msg = "OPENAI_API_KEY is required or configure a provider supported by LangChainGo: " + msg
+		}
+		return nil, &Error{Kind: ErrLLM, Message: msg}
+	}
+	maxIter := opts.MaxIterations
+	if maxIter <= 0 {
+		maxIter = DefaultMaxIterations
+	}
+	cg, err := newMCP(opts.CGMCPHTTP)
+	if err != nil {
+		return nil, &Error{Kind: ErrConfig, Message: "CoinGecko MCP: " + err.Error()}
+	}
+	x, err := newMCP(opts.XMCPHTTP)
+	if err != nil {
+		return nil, &Error{Kind: ErrConfig, Message: "X MCP: " + err.Error()}
+	}
+	return &Agent{
+		cg:      cg,
+		x:       x,
+		llm:     tracedLLM{Model: model, model: opts.Model},
+		maxIter: maxIter,
+	}, nil
+}
+
+// Discover lists the CoinGecko tools now instead of on the first Run, e.g. to
+// warm up at startup. It is a no-op once tools have been discovered.
+func (a *Agent) Discover(ctx context.Context) error {
+	_, err := a.tools(ctx)
+	return err
+}
+
+// tools returns the discovered CoinGecko tools, listing them on first use.
+func (a *Agent) tools(ctx context.Context) ([]tools.Tool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cgTools != nil {
+		return a.cgTools, nil
+	}
+	list, err := cgDiscoveredTools(ctx, a.cg)
+	if err != nil {
+		return nil, fmt.Errorf("discover CoinGecko tools: %w", err)
+	}
+	a.cgTools = list
+	return list, nil
+}
+
+// Run answers req; it has the Runner signature, so a.Run can be used wherever
+// a Runner is. Without DryRun and with ReplyTo set, the agent is asked to post
+// the answer with x_post_reply, and finishing without a successful post is an
+// error. Failures are returned as *Error together with what the run produced;
+// when the executor fails the Answer is empty and the tool output so far is
+// only in Steps.
+func (a *Agent) Run(ctx context.Context, req Request) (Result, error) {
+	cgTools, err := a.tools(ctx)
+	if err != nil {
+		return Result{}, &Error{Kind: ErrDiscovery, Message: err.Error()}
+	}
+	posted := &postRecord{}
+	toolsList := filterTools(cgTools, req.Tools)
+	if !req.DryRun {
+		toolsList = append(toolsList, xTool{client: a.x, posted: posted})
+	}
+
+	exec, err := agents.Initialize(
+		a.llm,
+		toolsList,
+		agents.ZeroShotReactDescription,
+		agents.WithMaxIterations(a.maxIter),
+		agents.WithReturnIntermediateSteps(),
+		agents.WithParserErrorHandler(agents.NewParserErrorHandler(nil)),
+	)
+	if err != nil {
+		return Result{}, &Error{Kind: ErrInternal, Message: err.Error()}
+	}
+
+	replyTo := strings.TrimSpace(req.ReplyTo)
+	prompt := req.Question
+	if !req.DryRun && replyTo != "" {
+		prompt = fmt.Sprintf("%s Answer this question using CoinGecko MCP. Then reply to tweet %s using x_post_reply.", prompt, replyTo)
+	}
+
+	stats := &runStats{}
+	out, err := exec.Call(withRunStats(ctx, stats), map[string]any{"input": prompt})
+	raw, _ := out["intermediateSteps"].([]schema.AgentStep)
+	res := Result{
+		Steps:         steps(raw),
+		Posted:        posted.ok,
+		PostedTweetID: posted.tweetID,
+		Iterations:    stats.calls,
+		Usage:         stats.usage,
+	}
+	for _, st := range res.Steps {
+		res.Tools = append(res.Tools, st.Tool)
+	}
+	if err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+		return res, classify(ctx, err, stats)
+	}
+	res.Answer = strings.TrimSpace(fmt.Sprint(out["output"]))
+	if !req.DryRun && replyTo != "" && !res.Posted {
+		if posted.err != "" {
+			return res, &Error{Kind: ErrPost, Message: posted.err}
+		}
+		return res, &Error{Kind: ErrNotPosted, Message: "finished without calling x_post_reply"}
+	}
+	return res, nil
+}
+
+// steps converts the executor's intermediate steps, each input on one line and
+// each observation abridged to maxObservation runes.
+func steps(raw []schema.AgentStep) []types.AgentStep {
+	var out []types.AgentStep
+	for _, st := range raw {
+		obs := []rune(oneLine(st.Observation))
+		if len(obs) > maxObservation {
+			obs = append(obs[:maxObservation], '…')
+		}
+		out = append(out, types.AgentStep{Tool: st.Action.Tool, Input: oneLine(st.Action.ToolInput), Observation: string(obs)})
+	}
+	return out
+}
+
+// filterTools keeps the tools named in allow, or all of them when allow is
+// empty. The result never shares spare capacity with all, so callers may
+// append to it while other Runs use the same discovered tools.
+func filterTools(all []tools.Tool, allow []string) []tools.Tool {
+	if len(allow) == 0 {
+		return slices.Clip(all)
+	}
+	keep := make(map[string]bool, len(allow))
+	for _, name := range allow {
+		keep[strings.TrimSpace(name)] = true
+	}
+	var out []tools.Tool
+	for _, t := range all {
+		if keep[t.Name()] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// tracedLLM wraps each model call in an "llm.generate" span.
+type tracedLLM struct {
+	llms.Model
+	model string
+}
+
+func (l tracedLLM) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	ctx, span := tracing.Start(ctx, "llm.generate", attribute.String("llm.model", l.model))
+	resp, err := l.Model.GenerateContent(ctx, messages, options...)
+	if stats, ok := ctx.Value(runStatsKey{}).(*runStats); ok {
+		stats.record(resp, err)
+	}
+	tracing.End(span, err)
+	return resp, err
+}
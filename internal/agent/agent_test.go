@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/tmc/langchaingo/llms"
+)
+
+// finalLLM answers every prompt straight away.
+type finalLLM struct{}
+
+func (finalLLM) GenerateContent(context.Context, []llms.MessageContent, ...llms.CallOption) (*llms.ContentResponse, error) {
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "Final Answer: BTC is $1"}}}, nil
+}
+
+func (l finalLLM) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, l, prompt, options...)
+}
+
+func TestRunConcurrently(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "tools/list":
+			// The nameless tool is dropped, leaving spare capacity in the discovered list.
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"tools":[{"name":"get_price","description":"Coin prices"},{"description":"no name"}]}}`))
+		default:
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+		}
+	}))
+	defer srv.Close()
+
+	cg, err := newMCP(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := newMCP(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &Agent{cg: cg, x: x, llm: finalLLM{}, maxIter: DefaultMaxIterations}
+	if err := a.Discover(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := a.Run(context.Background(), Request{Question: fmt.Sprintf("price of btc #%d?", i)})
+			if err != nil || res.Answer != "BTC is $1" {
+				t.Errorf("Run = %q, %v", res.Answer, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if len(a.cgTools) != 1 || a.cgTools[0].Name() != "get_price" {
+		t.Errorf("discovered tools changed: %v", a.cgTools)
+	}
+}
@@ -0,0 +1,244 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"cg-mentions-bot/internal/tracing"
+
+	"github.com/tmc/langchaingo/tools"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// mcpHTTP is a minimal JSON-RPC client for a stateless streamable HTTP MCP server.
+type mcpHTTP struct {
+	base string
+	hc   *http.Client
+
+	mu          sync.Mutex
+	initialized bool
+}
+
+// rpcError is a JSON-RPC error response.
+type rpcError struct {
+	Message string `json:"message"`
+}
+
+// newMCP checks base and returns a client for it. The MCP handshake happens on
+// the first call, so the server does not need to be up yet.
+func newMCP(base string) (*mcpHTTP, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("mcp url %q: %w", base, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("mcp url %q: want http(s)://host/path", base)
+	}
+	return &mcpHTTP{base: base, hc: &http.Client{Timeout: 60 * time.Second}}, nil
+}
+
+// initialize sends the MCP initialize request once; after a failure it is
+// tried again on the next call.
+func (m *mcpHTTP) initialize(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.initialized {
+		return nil
+	}
+	var out struct {
+		Error *rpcError `json:"error"`
+	}
+	if err := postJSON(ctx, m.hc, m.base, map[string]any{
+		"jsonrpc": "2.0", "id": 1, "method": "initialize",
+		"params": map[string]any{
+			"protocolVersion": "2025-06-18",
+			"capabilities":    map[string]any{},
+			"clientInfo":      map[string]any{"name": "lc", "version": "0.1"},
+		},
+	}, &out); err != nil {
+		return fmt.Errorf("initialize %s: %w", m.base, err)
+	}
+	if out.Error != nil {
+		return fmt.Errorf("initialize %s: %s", m.base, out.Error.Message)
+	}
+	m.initialized = true
+	return nil
+}
+
+func (m *mcpHTTP) call(ctx context.Context, name string, args map[string]any) (string, error) {
+	text, _, err := m.callTool(ctx, name, args)
+	return text, err
+}
+
+// callTool calls a tool and returns its first text content and whether the
+// server flagged the result as an error.
+func (m *mcpHTTP) callTool(ctx context.Context, name string, args map[string]any) (string, bool, error) {
+	var out struct {
+		Result struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+			IsError bool `json:"isError"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+	if err := m.initialize(ctx); err != nil {
+		return "", false, err
+	}
+	if err := postJSON(ctx, m.hc, m.base, map[string]any{
+		"jsonrpc": "2.0", "id": 2, "method": "tools/call",
+		"params": map[string]any{"name": name, "arguments": args},
+	}, &out); err != nil {
+		return "", false, err
+	}
+	if out.Error != nil {
+		return "", false, fmt.Errorf("%s: %s", name, out.Error.Message)
+	}
+	if len(out.Result.Content) == 0 {
+		return "", out.Result.IsError, nil
+	}
+	return out.Result.Content[0].Text, out.Result.IsError, nil
+}
+
+func (m *mcpHTTP) listTools(ctx context.Context) ([]map[string]any, error) {
+	var out struct {
+		Result struct {
+			Tools []map[string]any `json:"tools"`
+		} `json:"result"`
+		Error *rpcError `json:"error"`
+	}
+	if err := m.initialize(ctx); err != nil {
+		return nil, err
+	}
+	if err := postJSON(ctx, m.hc, m.base, map[string]any{
+		"jsonrpc": "2.0", "id": 3, "method": "tools/list",
+	}, &out); err != nil {
+		return nil, err
+	}
+	if out.Error != nil {
+		return nil, fmt.Errorf("tools/list: %s", out.Error.Message)
+	}
+	return out.Result.Tools, nil
+}
+
+// postJSON posts body as JSON to url and decodes the response into out. A
+// non-2xx status is an error.
+func postJSON(ctx context.Context, c *http.Client, url string, body, out any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if len(bytes.TrimSpace(msg)) > 0 {
+			return fmt.Errorf("%s: status %d: %s", url, resp.StatusCode, bytes.TrimSpace(msg))
+		}
+		return fmt.Errorf("%s: status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+type genericMCPTool struct {
+	client *mcpHTTP
+	name   string
+	desc   string
+}
+
+func (t genericMCPTool) Name() string        { return t.name }
+func (t genericMCPTool) Description() string { return t.desc }
+func (t genericMCPTool) Call(ctx context.Context, input string) (string, error) {
+	var a map[string]any
+	_ = json.Unmarshal([]byte(input), &a)
+	ctx, span := tracing.Start(ctx, "tool "+t.name, attribute.String("mcp.tool", t.name))
+	out, err := t.client.call(ctx, t.name, a)
+	tracing.End(span, err)
+	return out, err
+}
+
+// xTool posts the reply through xmcp and records the outcome in posted, which
+// is per Run.
+type xTool struct {
+	client *mcpHTTP
+	posted *postRecord
+}
+
+// postRecord is what x_post_reply did during one Run.
+type postRecord struct {
+	mu      sync.Mutex
+	ok      bool
+	tweetID string
+	err     string
+}
+
+func (t xTool) Name() string { return "x_post_reply" }
+func (t xTool) Description() string {
+	return "Reply under a tweet via X MCP. Input JSON: {\"in_reply_to_tweet_id\":\"...\",\"text\":\"...\"}"
+}
+func (t xTool) Call(ctx context.Context, input string) (string, error) {
+	var a map[string]any
+	_ = json.Unmarshal([]byte(input), &a)
+	ctx, span := tracing.Start(ctx, "tool "+t.Name(), attribute.String("mcp.tool", "twitter.post_reply"))
+	out, isError, err := t.client.callTool(ctx, "twitter.post_reply", a)
+	if err == nil && isError {
+		err = errors.New(out)
+	}
+	tracing.End(span, err)
+	t.posted.mu.Lock()
+	defer t.posted.mu.Unlock()
+	if err != nil {
+		// Leave it to the LLM to retry or give up; Run reports the failure.
+		t.posted.err = err.Error()
+		return "post failed: " + err.Error(), nil
+	}
+	var reply struct {
+		TweetID string `json:"tweet_id"`
+	}
+	_ = json.Unmarshal([]byte(out), &reply)
+	t.posted.ok, t.posted.tweetID, t.posted.err = true, reply.TweetID, ""
+	return out, nil
+}
+
+// cgDiscoveredTools wraps every tool the CoinGecko MCP server lists, with its
+// input schema in the description to guide the LLM.
+func cgDiscoveredTools(ctx context.Context, cg *mcpHTTP) ([]tools.Tool, error) {
+	raw, err := cg.listTools(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]tools.Tool, 0, len(raw))
+	for _, t := range raw {
+		name, _ := t["name"].(string)
+		if name == "" {
+			continue
+		}
+		description, _ := t["description"].(string)
+		// include inputSchema (if any) as a compact JSON to guide the LLM
+		if schemaVal, ok := t["inputSchema"]; ok && schemaVal != nil {
+			if b, err := json.Marshal(schemaVal); err == nil {
+				description = fmt.Sprintf("%s\nInput JSON must match schema: %s", description, string(b))
+			}
+		}
+		out = append(out, genericMCPTool{client: cg, name: name, desc: description})
+	}
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewMCPRejectsBadURL(t *testing.T) {
+	for _, u := range []string{"", "localhost:8081/mcp", "http://", "http://bad host/mcp", "ftp://x/mcp"} {
+		if _, err := newMCP(u); err == nil {
+			t.Errorf("newMCP(%q) succeeded", u)
+		}
+	}
+}
+
+func TestMCPCallErrors(t *testing.T) {
+	var initFails, callStatus int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch {
+		case req.Method == "initialize" && initFails > 0:
+			initFails--
+			http.Error(w, "starting up", http.StatusServiceUnavailable)
+		case req.Method == "initialize":
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{}}`))
+		case callStatus != 0:
+			http.Error(w, "boom", callStatus)
+		default:
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":2,"result":{"content":[{"type":"text","text":"ok"}]}}`))
+		}
+	}))
+	defer srv.Close()
+
+	m, err := newMCP(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	initFails = 1
+	if _, err := m.call(ctx, "ping", nil); err == nil || !strings.Contains(err.Error(), "initialize") {
+		t.Fatalf("call with failing initialize = %v, want an initialize error", err)
+	}
+	if got, err := m.call(ctx, "ping", nil); err != nil || got != "ok" {
+		t.Fatalf("call after recovery = %q, %v", got, err)
+	}
+
+	callStatus = http.StatusBadGateway
+	if _, err := m.call(ctx, "ping", nil); err == nil || !strings.Contains(err.Error(), "502") {
+		t.Fatalf("call with 502 = %v, want a status error", err)
+	}
+	if _, err := m.listTools(ctx); err == nil {
+		t.Fatal("listTools with 502 succeeded")
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/tmc/langchaingo/agents"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// Kinds of Error.
+const (
+	// ErrDiscovery: the CoinGecko tools could not be listed.
+	ErrDiscovery = "tool_discovery"
+	// ErrLLM: a model call failed.
+	ErrLLM = "llm"
+	// ErrParse: the model's output could not be parsed as a ReAct step.
+	ErrParse = "parse"
+	// ErrMaxIterations: the agent did not finish within MaxIterations.
+	ErrMaxIterations = "max_iterations"
+	// ErrPost: x_post_reply failed and no reply was posted.
+	ErrPost = "post"
+	// ErrNotPosted: the agent finished without calling x_post_reply.
+	ErrNotPosted = "not_posted"
+	// ErrCanceled: the run was cancelled or timed out.
+	ErrCanceled = "canceled"
+	// ErrConfig: the agent options are invalid, e.g. a malformed MCP URL.
+	ErrConfig = "config"
+	// ErrOutput: the agent process exited without valid -json output.
+	ErrOutput = "output"
+	// ErrInternal: anything else.
+	ErrInternal = "internal"
+)
+
+// Error is a failed agent run, typed by Kind so the bot can tell a model
+// failure from a failed post.
+type Error struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Kind + ": " + e.Message
+}
+
+// Usage is the token usage of a run, summed over its model calls.
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// Output is what "agent -json" prints on stdout: the Result and, when the run
+// failed, the typed error.
+type Output struct {
+	Result
+	Error *Error `json:"error,omitempty"`
+}
+
+// runStats collects the model calls of one Run; tracedLLM finds it in the context.
+type runStats struct {
+	mu     sync.Mutex
+	calls  int
+	usage  Usage
+	llmErr error
+}
+
+type runStatsKey struct{}
+
+func withRunStats(ctx context.Context, s *runStats) context.Context {
+	return context.WithValue(ctx, runStatsKey{}, s)
+}
+
+func (s *runStats) record(resp *llms.ContentResponse, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if err != nil {
+		s.llmErr = err
+		return
+	}
+	for _, c := range resp.Choices {
+		s.usage.PromptTokens += intInfo(c.GenerationInfo, "PromptTokens")
+		s.usage.CompletionTokens += intInfo(c.GenerationInfo, "CompletionTokens")
+		s.usage.TotalTokens += intInfo(c.GenerationInfo, "TotalTokens")
+	}
+}
+
+func intInfo(info map[string]any, key string) int {
+	n, _ := info[key].(int)
+	return n
+}
+
+// classify turns the executor's error into a typed Error.
+func classify(ctx context.Context, err error, stats *runStats) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case ctx.Err() != nil:
+		return &Error{Kind: ErrCanceled, Message: err.Error()}
+	case stats.llmErr != nil:
+		return &Error{Kind: ErrLLM, Message: stats.llmErr.Error()}
+	case errors.Is(err, agents.ErrNotFinished):
+		return &Error{Kind: ErrMaxIterations, Message: err.Error()}
+	case errors.Is(err, agents.ErrUnableToParseOutput):
+		return &Error{Kind: ErrParse, Message: err.Error()}
+	}
+	return &Error{Kind: ErrInternal, Message: err.Error()}
+}
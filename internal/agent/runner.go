@@ -3,51 +3,95 @@ package agent
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
+	"time"
+
+	"cg-mentions-bot/internal/tracing"
+	"cg-mentions-bot/internal/types"
 )
 
-// Runner invokes the agent executable with a question and optional reply-to tweet id.
-type Runner func(ctx context.Context, question string, replyTo string) (string, error)
-
-// NewRunner constructs a Runner for the given agent command. It inherits the current
-// process environment and optionally overrides common agent envs if set:
-//   - AGENT_CG_MCP_HTTP, AGENT_X_MCP_HTTP, OPENAI_API_KEY, OPENAI_MODEL
-func NewRunner(agentCmd string) Runner {
-	return func(ctx context.Context, question string, replyTo string) (string, error) {
-		args := []string{"-q", question}
-		if replyTo != "" {
-			args = append(args, "-reply-to", replyTo)
-		}
-		cmd := exec.CommandContext(ctx, agentCmd, args...)
-		// Inherit env, apply optional overrides
-		env := os.Environ()
-		if v := os.Getenv("AGENT_CG_MCP_HTTP"); v != "" {
-			env = append(env, fmt.Sprintf("CG_MCP_HTTP=%s", v))
-		}
-		if v := os.Getenv("AGENT_X_MCP_HTTP"); v != "" {
-			env = append(env, fmt.Sprintf("X_MCP_HTTP=%s", v))
-		}
-		if v := os.Getenv("OPENAI_API_KEY"); v != "" {
-			env = append(env, fmt.Sprintf("OPENAI_API_KEY=%s", v))
+// Request is one question for the agent.
+type Request struct {
+	Question string
+	// ReplyTo is the tweet the agent should reply under with x_post_reply.
+	ReplyTo string
+	// DryRun answers without giving the agent the x_post_reply tool.
+	DryRun bool
+	// Tools restricts the CoinGecko tools the agent may call; empty allows all.
+	Tools []string
+}
+
+// Result is what the agent produced for a Request. It is also the JSON the
+// agent binary prints with -json (see Output).
+type Result struct {
+	Answer string `json:"answer"`
+	// Posted reports that x_post_reply succeeded, and PostedTweetID is the
+	// reply's ID when xmcp returned it.
+	Posted        bool   `json:"posted"`
+	PostedTweetID string `json:"posted_tweet_id,omitempty"`
+	// Tools lists the tools the agent called, in order.
+	Tools []string `json:"tools,omitempty"`
+	// Steps are the agent's intermediate steps: each tool call with its input
+	// (the arguments) and (abridged) observation.
+	Steps []types.AgentStep `json:"tool_calls,omitempty"`
+	// Iterations counts the model calls of the run.
+	Iterations int   `json:"iterations"`
+	Usage      Usage `json:"usage"`
+}
+
+// waitDelay bounds how long a cancelled agent may keep its output pipes open.
+const waitDelay = 2 * time.Second
+
+// Runner invokes the agent executable for a request.
+type Runner func(ctx context.Context, req Request) (Result, error)
+
+// NewRunner constructs a Runner for the given agent command, run with -json.
+// The agent inherits the current process environment with env ("KEY=value",
+// e.g. CG_MCP_HTTP and OPENAI_API_KEY from the bot's config) applied on top.
+// A failed run returns the agent's typed *Error; output that is not the -json
+// contract is an ErrOutput.
+func NewRunner(agentCmd string, env []string) Runner {
+	return func(ctx context.Context, req Request) (Result, error) {
+		args := []string{"-json", "-q", req.Question}
+		if req.DryRun {
+			args = append(args, "-dry-run")
+		} else if req.ReplyTo != "" {
+			args = append(args, "-reply-to", req.ReplyTo)
 		}
-		if v := os.Getenv("OPENAI_MODEL"); v != "" {
-			env = append(env, fmt.Sprintf("OPENAI_MODEL=%s", v))
+		if len(req.Tools) > 0 {
+			args = append(args, "-tools", strings.Join(req.Tools, ","))
 		}
-		cmd.Env = env
+		cmd := exec.CommandContext(ctx, agentCmd, args...)
+		// Inherit env, apply the configured overrides
+		env := append(os.Environ(), env...)
+		// Continue the caller's trace inside the agent.
+		cmd.Env = append(env, tracing.Env(ctx)...)
+		// On cancellation (e.g. shutdown), don't wait for grandchildren holding the pipes.
+		cmd.WaitDelay = waitDelay
 		var outBuf, errBuf bytes.Buffer
 		cmd.Stdout = &outBuf
 		cmd.Stderr = &errBuf
-		err := cmd.Run()
-		stdout := outBuf.String()
-		if err != nil {
-			// Return stdout if present, but include error and stderr for visibility
-			if stdout != "" {
-				return stdout, fmt.Errorf("agent error: %v; stderr: %s", err, errBuf.String())
+		runErr := cmd.Run()
+
+		// The agent exits non-zero on a typed error, so its JSON wins over the exit status.
+		var out Output
+		if err := json.Unmarshal(outBuf.Bytes(), &out); err != nil {
+			res := Result{Answer: strings.TrimSpace(outBuf.String())}
+			if runErr != nil {
+				return res, fmt.Errorf("agent error: %v; stderr: %s", runErr, errBuf.String())
 			}
-			return "", fmt.Errorf("agent error: %v; stderr: %s", err, errBuf.String())
+			return res, &Error{Kind: ErrOutput, Message: fmt.Sprintf("invalid -json output: %v", err)}
+		}
+		if out.Error != nil {
+			return out.Result, out.Error
+		}
+		if runErr != nil {
+			return out.Result, fmt.Errorf("agent error: %v; stderr: %s", runErr, errBuf.String())
 		}
-		return stdout, nil
+		return out.Result, nil
 	}
 }
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cg-mentions-bot/internal/types"
+)
+
+// fakeAgent writes a shell script standing in for the agent binary.
+func fakeAgent(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "agent")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunnerParsesResult(t *testing.T) {
+	cmd := fakeAgent(t, `echo "$*" > "$ARGS_FILE"
+echo '{"answer":"BTC is $67,000","posted":true,"posted_tweet_id":"99","tools":["get_simple_price","x_post_reply"],'\
+'"tool_calls":[{"tool":"get_simple_price","input":"{}","observation":"{\"bitcoin\":{}}"}],'\
+'"iterations":3,"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}'`)
+	argsFile := filepath.Join(t.TempDir(), "args")
+
+	run := NewRunner(cmd, []string{"ARGS_FILE=" + argsFile})
+	res, err := run(context.Background(), Request{Question: "btc", ReplyTo: "42", Tools: []string{"get_simple_price", "get_search"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Result{
+		Answer:        "BTC is $67,000",
+		Posted:        true,
+		PostedTweetID: "99",
+		Tools:         []string{"get_simple_price", "x_post_reply"},
+		Steps:         []types.AgentStep{{Tool: "get_simple_price", Input: "{}", Observation: `{"bitcoin":{}}`}},
+		Iterations:    3,
+		Usage:         Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}
+	if !reflect.DeepEqual(res, want) {
+		t.Fatalf("Result = %+v\nwant %+v", res, want)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if got := strings.TrimSpace(string(args)); got != "-json -q btc -reply-to 42 -tools get_simple_price,get_search" {
+		t.Fatalf("args = %q", got)
+	}
+
+	if _, err := run(context.Background(), Request{Question: "btc", ReplyTo: "42", DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	args, _ = os.ReadFile(argsFile)
+	if got := strings.TrimSpace(string(args)); got != "-json -q btc -dry-run" {
+		t.Fatalf("dry-run args = %q", got)
+	}
+}
+
+func TestRunnerTypedError(t *testing.T) {
+	cmd := fakeAgent(t, `echo '{"answer":"","posted":false,"iterations":2,"error":{"kind":"not_posted","message":"finished without calling x_post_reply"}}'
+exit 1`)
+	res, err := NewRunner(cmd, nil)(context.Background(), Request{Question: "btc", ReplyTo: "42"})
+	var aerr *Error
+	if !errors.As(err, &aerr) || aerr.Kind != ErrNotPosted {
+		t.Fatalf("err = %v, want a not_posted *Error", err)
+	}
+	if res.Iterations != 2 || res.Posted {
+		t.Fatalf("Result = %+v", res)
+	}
+}
+
+func TestRunnerInvalidOutput(t *testing.T) {
+	run := NewRunner(fakeAgent(t, `echo "BTC is up"`), nil)
+	res, err := run(context.Background(), Request{Question: "btc"})
+	var aerr *Error
+	if !errors.As(err, &aerr) || aerr.Kind != ErrOutput {
+		t.Fatalf("err = %v, want an output *Error", err)
+	}
+	if res.Answer != "BTC is up" {
+		t.Fatalf("Answer = %q", res.Answer)
+	}
+
+	run = NewRunner(fakeAgent(t, `echo "boom" >&2; exit 2`), nil)
+	if _, err := run(context.Background(), Request{Question: "btc"}); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("err = %v, want the agent's stderr", err)
+	}
+}
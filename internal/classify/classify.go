@@ -0,0 +1,157 @@
+package classify
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Category is the intent assigned to a mention.
+type Category string
+
+const (
+	CryptoQuestion Category = "crypto_question"
+	Greeting       Category = "greeting"
+	Spam           Category = "spam"
+	OffTopic       Category = "off_topic"
+	Abuse          Category = "abuse"
+)
+
+// Categories lists every category in a stable order.
+var Categories = []Category{CryptoQuestion, Greeting, Spam, OffTopic, Abuse}
+
+// Classifier tags mention text with a Category using cheap heuristics first and,
+// when they are not confident, an optional LLM.
+type Classifier struct {
+	// LLM, if set, is asked to pick a category for ambiguous text.
+	LLM func(ctx context.Context, prompt string) (string, error)
+	// Policy decides what to do with each category.
+	Policy Policy
+}
+
+// Classify returns the category for the raw tweet text.
+func (c Classifier) Classify(ctx context.Context, text string) (Category, error) {
+	cat, confident := Heuristic(text)
+	if confident || c.LLM == nil {
+		return cat, nil
+	}
+	out, err := c.LLM(ctx, llmPrompt(text))
+	if err != nil {
+		return cat, fmt.Errorf("classify: %w", err)
+	}
+	if parsed, ok := Parse(out); ok {
+		return parsed, nil
+	}
+	return cat, nil
+}
+
+// Parse maps a category name (as produced by an LLM) to a Category.
+func Parse(s string) (Category, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.Trim(s, "`\"'. ")
+	for _, c := range Categories {
+		if s == string(c) {
+			return c, true
+		}
+	}
+	return "", false
+}
+
+func llmPrompt(text string) string {
+	return fmt.Sprintf(`Classify this tweet sent to a crypto market data bot into exactly one category:
+crypto_question (asks about coin prices, market caps, volumes, trends or other crypto market data),
+greeting (gm, hello, thanks and similar with no question),
+spam (promotions, airdrops, scams, link dumps),
+off_topic (anything unrelated to crypto market data),
+abuse (insults, harassment).
+Reply with the category name only.
+
+Tweet: %s`, text)
+}
+
+var (
+	urlRe     = regexp.MustCompile(`https?://\S+`)
+	handleRe  = regexp.MustCompile(`@[A-Za-z0-9_]+`)
+	cashtagRe = regexp.MustCompile(`\$[A-Za-z][A-Za-z0-9]{1,9}\b`)
+	wordRe    = regexp.MustCompile(`[a-z0-9]+`)
+)
+
+var cryptoWords = set(
+	"price", "prices", "market", "cap", "marketcap", "mcap", "volume", "ath", "atl", "chart",
+	"coin", "coins", "token", "tokens", "crypto", "trending", "gainers", "losers", "dominance",
+	"fdv", "supply", "circulating", "exchange", "exchanges", "defi", "nft", "nfts", "stablecoin",
+	"btc", "bitcoin", "eth", "ethereum", "sol", "solana", "bnb", "xrp", "ada", "cardano", "doge",
+	"dogecoin", "usdt", "usdc", "pepe", "shib", "trx", "avax", "matic", "ltc",
+	"usd", "eur", "brl", "gbp", "jpy", "worth", "cost", "rank", "pump", "dump", "rally",
+)
+
+var greetingWords = set(
+	"gm", "gn", "hi", "hello", "hey", "yo", "sup", "thanks", "thank", "thx", "ty", "you",
+	"wagmi", "lfg", "good", "morning", "night", "evening", "fren", "frens", "ser", "nice", "cool",
+	"love", "great", "bot", "merhaba", "hola", "ola", "gracias", "obrigado", "tesekkurler",
+)
+
+var spamWords = set(
+	"airdrop", "giveaway", "dm", "whitelist", "presale", "claim", "1000x", "100x", "promo",
+	"follow", "retweet", "winner", "wallet", "connect", "bonus", "referral", "signup",
+)
+
+var abuseWords = set(
+	"idiot", "stupid", "dumb", "moron", "scammer", "fuck", "fucking", "shit", "bitch", "asshole",
+	"retard", "loser", "trash", "garbage", "kys",
+)
+
+// Heuristic classifies text with keyword rules. The boolean reports whether
+// the rules are confident; ambiguous text defaults to CryptoQuestion.
+func Heuristic(text string) (Category, bool) {
+	urls := len(urlRe.FindAllString(text, -1))
+	cashtags := len(cashtagRe.FindAllString(text, -1))
+	clean := strings.ToLower(handleRe.ReplaceAllString(urlRe.ReplaceAllString(text, " "), " "))
+	words := wordRe.FindAllString(clean, -1)
+
+	var crypto, greet, spam, abuse int
+	for _, w := range words {
+		switch {
+		case abuseWords[w]:
+			abuse++
+		case spamWords[w]:
+			spam++
+		case cryptoWords[w]:
+			crypto++
+		}
+		if greetingWords[w] {
+			greet++
+		}
+	}
+	crypto += cashtags
+
+	spammy := urls >= 2 || spam >= 2 || (spam >= 1 && urls >= 1)
+	switch {
+	case (abuse > 0 || spammy) && crypto > 0:
+		// A rude or link-carrying question about a coin is still a question;
+		// let the LLM (if any) make the call instead of dropping it.
+		return CryptoQuestion, false
+	case abuse > 0:
+		return Abuse, true
+	case spammy:
+		return Spam, true
+	case len(words) == 0 && cashtags == 0:
+		return OffTopic, true
+	case crypto > 0:
+		return CryptoQuestion, true
+	case greet == len(words):
+		return Greeting, true
+	case !strings.Contains(clean, "?"):
+		return OffTopic, false
+	}
+	return CryptoQuestion, false
+}
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
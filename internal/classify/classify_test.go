@@ -0,0 +1,51 @@
+package classify
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHeuristic(t *testing.T) {
+	tests := []struct {
+		text      string
+		want      Category
+		confident bool
+	}{
+		{"@bot what is the price of $BTC?", CryptoQuestion, true},
+		{"@bot gm ser", Greeting, true},
+		{"@bot you are an idiot", Abuse, true},
+		{"@bot free airdrop, claim at https://x.example", Spam, true},
+		{"@bot https://a.example https://b.example", Spam, true},
+		{"@bot", OffTopic, true},
+		// Crypto questions with abuse or spam signals are left to the LLM.
+		{"@bot is PEPE a scam? idiot devs", CryptoQuestion, false},
+		{"@bot why is btc dumping, shit", CryptoQuestion, false},
+		{"@bot should I connect wallet to https://x.example for $SOL?", CryptoQuestion, false},
+		{"@bot what time is it?", CryptoQuestion, false},
+		{"@bot nice weather today", OffTopic, false},
+	}
+	for _, tt := range tests {
+		got, confident := Heuristic(tt.text)
+		if got != tt.want || confident != tt.confident {
+			t.Errorf("Heuristic(%q) = %s, %v; want %s, %v", tt.text, got, confident, tt.want, tt.confident)
+		}
+	}
+}
+
+func TestClassifyAsksLLMWhenUnsure(t *testing.T) {
+	asked := 0
+	c := Classifier{LLM: func(context.Context, string) (string, error) {
+		asked++
+		return "abuse.", nil
+	}}
+	got, err := c.Classify(context.Background(), "@bot why is btc dumping, shit")
+	if err != nil || got != Abuse {
+		t.Fatalf("Classify = %s, %v; want abuse", got, err)
+	}
+	if got, _ := c.Classify(context.Background(), "@bot price of $ETH"); got != CryptoQuestion {
+		t.Fatalf("Classify = %s; want crypto_question", got)
+	}
+	if asked != 1 {
+		t.Fatalf("LLM asked %d times, want 1", asked)
+	}
+}
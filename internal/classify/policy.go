@@ -0,0 +1,81 @@
+package classify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Action is what the pipeline does with a classified mention.
+type Action string
+
+const (
+	ActionAnswer Action = "answer"
+	ActionCanned Action = "canned"
+	ActionIgnore Action = "ignore"
+)
+
+// Rule is the configured handling for one category.
+type Rule struct {
+	Action Action
+	// Reply is the text posted for ActionCanned.
+	Reply string
+}
+
+// Policy maps categories to rules. Categories without a rule are answered.
+type Policy map[Category]Rule
+
+// DefaultPolicy answers questions, greets back and ignores everything else.
+func DefaultPolicy() Policy {
+	return Policy{
+		CryptoQuestion: {Action: ActionAnswer},
+		Greeting:       {Action: ActionCanned, Reply: "gm! Ask me about any coin's price, market cap or trend and I'll look it up on CoinGecko."},
+		Spam:           {Action: ActionIgnore},
+		OffTopic:       {Action: ActionIgnore},
+		Abuse:          {Action: ActionIgnore},
+	}
+}
+
+// Rule returns the rule for c.
+func (p Policy) Rule(c Category) Rule {
+	if r, ok := p[c]; ok {
+		return r
+	}
+	return Rule{Action: ActionAnswer}
+}
+
+// ParsePolicy applies overrides of the form "greeting=canned,off_topic=ignore" to p.
+func ParsePolicy(p Policy, s string) (Policy, error) {
+	out := Policy{}
+	for c, r := range p {
+		out[c] = r
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid policy entry %q (want category=action)", part)
+		}
+		c, ok := Parse(k)
+		if !ok {
+			return nil, fmt.Errorf("unknown category %q", k)
+		}
+		a := Action(strings.ToLower(strings.TrimSpace(v)))
+		switch a {
+		case ActionAnswer, ActionCanned, ActionIgnore:
+		default:
+			return nil, fmt.Errorf("unknown action %q for %s", v, c)
+		}
+		r := out[c]
+		r.Action = a
+		out[c] = r
+	}
+	for c, r := range out {
+		if r.Action == ActionCanned && strings.TrimSpace(r.Reply) == "" {
+			return nil, fmt.Errorf("category %s uses canned but has no reply text", c)
+		}
+	}
+	return out, nil
+}
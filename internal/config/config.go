@@ -0,0 +1,261 @@
+// Package config loads the settings shared by the bot, the agent and the MCP
+// servers: defaults, then an optional YAML file, then environment variables,
+// validated once at startup. The policy section can be reloaded while the bot
+// runs (see Watch).
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvFile names the environment variable holding the config file path, used
+// when no -config flag is given.
+const EnvFile = "CONFIG_FILE"
+
+// Config is the whole configuration. Each field's env tag lists the variables
+// that override it, first set wins.
+type Config struct {
+	Server   Server   `yaml:"server"`
+	Webhook  Webhook  `yaml:"webhook"`
+	X        X        `yaml:"x"`
+	Agent    Agent    `yaml:"agent"`
+	LLM      LLM      `yaml:"llm"`
+	Legacy   Legacy   `yaml:"legacy"`
+	Classify Classify `yaml:"classify"`
+	Entities Entities `yaml:"entities"`
+	Stale    Stale    `yaml:"stale"`
+	Retry    Retry    `yaml:"retry"`
+	// Policy is reloaded on SIGHUP or when the file changes.
+	Policy  Policy  `yaml:"policy"`
+	XMCP    XMCP    `yaml:"xmcp"`
+	CGProxy CGProxy `yaml:"cgproxy"`
+}
+
+// Server holds the bot's HTTP server, queue and store settings.
+type Server struct {
+	Port            string        `yaml:"port" env:"PORT"`
+	Workers         int           `yaml:"workers" env:"WORKERS"`
+	QueueSize       int           `yaml:"queue_size" env:"QUEUE_SIZE"`
+	JobTimeout      time.Duration `yaml:"job_timeout" env:"JOB_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	ReadyTimeout    time.Duration `yaml:"ready_timeout" env:"READY_TIMEOUT"`
+	StorePath       string        `yaml:"store_path" env:"STORE_PATH"`
+	AuditRetention  time.Duration `yaml:"audit_retention" env:"AUDIT_RETENTION"`
+	AdminToken      string        `yaml:"admin_token" env:"ADMIN_TOKEN"`
+}
+
+// Webhook holds the authentication of POST /mentions.
+type Webhook struct {
+	Secret      string        `yaml:"secret" env:"WEBHOOK_SECRET"`
+	HMACSecrets []string      `yaml:"hmac_secrets" env:"WEBHOOK_HMAC_SECRETS"`
+	MaxSkew     time.Duration `yaml:"max_skew" env:"WEBHOOK_MAX_SKEW"`
+}
+
+// X holds the X API credentials and the ways mentions arrive from X.
+type X struct {
+	Base        string `yaml:"base" env:"X_BASE"`
+	BearerToken string `yaml:"bearer_token" env:"X_BEARER_TOKEN"`
+	// AuthMode is "bearer" or "oauth1" for posting replies.
+	AuthMode       string `yaml:"auth_mode" env:"X_AUTH_MODE"`
+	ConsumerKey    string `yaml:"consumer_key" env:"X_CONSUMER_KEY"`
+	ConsumerSecret string `yaml:"consumer_secret" env:"X_CONSUMER_SECRET"`
+	AccessToken    string `yaml:"access_token" env:"X_ACCESS_TOKEN"`
+	AccessSecret   string `yaml:"access_secret" env:"X_ACCESS_SECRET"`
+
+	UserID              string        `yaml:"user_id" env:"X_USER_ID"`
+	Handle              string        `yaml:"handle" env:"X_HANDLE"`
+	PollInterval        time.Duration `yaml:"poll_interval" env:"X_POLL_INTERVAL"`
+	ActivityWebhookPath string        `yaml:"activity_webhook_path" env:"X_ACTIVITY_WEBHOOK_PATH"`
+	ThreadContext       bool          `yaml:"thread_context" env:"THREAD_CONTEXT_X"`
+	ThreadContextDepth  int           `yaml:"thread_context_depth" env:"THREAD_CONTEXT_DEPTH"`
+}
+
+// Agent holds how the bot runs the agent and the MCP servers it talks to.
+type Agent struct {
+	// Mode is "exec" to spawn Cmd per mention or "inprocess" to run one
+	// long-lived agent inside the bot.
+	Mode      string `yaml:"mode" env:"AGENT_MODE"`
+	Cmd       string `yaml:"cmd" env:"AGENT_CMD"`
+	CGMCPHTTP string `yaml:"cg_mcp_http" env:"AGENT_CG_MCP_HTTP,CG_MCP_HTTP"`
+	XMCPHTTP  string `yaml:"x_mcp_http" env:"AGENT_X_MCP_HTTP,X_MCP_HTTP"`
+}
+
+// LLM holds the OpenAI-compatible endpoint used by the agent and the classifier.
+type LLM struct {
+	APIKey  string `yaml:"api_key" env:"OPENAI_API_KEY"`
+	BaseURL string `yaml:"base_url" env:"OPENAI_BASE_URL"`
+	Model   string `yaml:"model" env:"OPENAI_MODEL"`
+}
+
+// Legacy holds the stdio MCP flow used without an agent (and by askcg).
+type Legacy struct {
+	MCPCmd  string `yaml:"mcp_cmd" env:"MCP_CMD"`
+	MCPTool string `yaml:"mcp_tool" env:"MCP_TOOL"`
+}
+
+// Classify holds the intent classifier settings. Replies are keyed by category;
+// CLASSIFY_REPLY_<CATEGORY> overrides one reply.
+type Classify struct {
+	Enabled bool              `yaml:"enabled" env:"CLASSIFY"`
+	LLM     bool              `yaml:"llm" env:"CLASSIFY_LLM"`
+	Model   string            `yaml:"model" env:"CLASSIFY_MODEL"`
+	Policy  string            `yaml:"policy" env:"CLASSIFY_POLICY"`
+	Replies map[string]string `yaml:"replies"`
+}
+
+// Entities holds the CoinGecko coins catalog used for coin and currency hints.
+type Entities struct {
+	Enabled bool          `yaml:"enabled" env:"ENTITIES"`
+	APIBase string        `yaml:"api_base" env:"COINGECKO_API_BASE"`
+	APIKey  string        `yaml:"api_key" env:"COINGECKO_API_KEY"`
+	Cache   string        `yaml:"cache" env:"COINGECKO_COINS_CACHE"`
+	TTL     time.Duration `yaml:"ttl" env:"COINGECKO_COINS_TTL"`
+}
+
+// Stale holds the handling of delayed mentions.
+type Stale struct {
+	Mode   string        `yaml:"mode" env:"STALE_MODE"`
+	After  time.Duration `yaml:"after" env:"STALE_AFTER"`
+	MaxAge time.Duration `yaml:"max_age" env:"STALE_MAX_AGE"`
+}
+
+// Retry holds the automatic replays of dead-lettered mentions.
+type Retry struct {
+	MaxAttempts int           `yaml:"max_attempts" env:"RETRY_MAX_ATTEMPTS"`
+	Interval    time.Duration `yaml:"interval" env:"RETRY_INTERVAL"`
+	Backoff     time.Duration `yaml:"backoff" env:"RETRY_BACKOFF"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" env:"RETRY_MAX_BACKOFF"`
+	Window      time.Duration `yaml:"window" env:"RETRY_WINDOW"`
+}
+
+// Policy holds the settings that take effect without a restart.
+type Policy struct {
+	// PostingMode is "auto" or "approval".
+	PostingMode string `yaml:"posting_mode" env:"POSTING_MODE"`
+	// ReplyPoster is "agent" (the agent calls x_post_reply) or "bot" (the
+	// agent only answers and the bot posts under the mention).
+	ReplyPoster string  `yaml:"reply_poster" env:"REPLY_POSTER"`
+	Authors     Authors `yaml:"authors"`
+	Prompts     Prompts `yaml:"prompts"`
+	// AgentTools restricts the CoinGecko tools the agent may call; empty allows all.
+	AgentTools []string `yaml:"agent_tools" env:"AGENT_TOOLS"`
+}
+
+// Authors holds the per-author block/allow lists and quotas.
+type Authors struct {
+	Allowlist     []string      `yaml:"allowlist" env:"AUTHOR_ALLOWLIST"`
+	Blocklist     []string      `yaml:"blocklist" env:"AUTHOR_BLOCKLIST"`
+	AllowlistOnly bool          `yaml:"allowlist_only" env:"AUTHOR_ALLOWLIST_ONLY"`
+	RateLimit     int           `yaml:"rate_limit" env:"AUTHOR_RATE_LIMIT"`
+	RateWindow    time.Duration `yaml:"rate_window" env:"AUTHOR_RATE_WINDOW"`
+}
+
+// Prompts holds text added to the questions sent to the agent.
+type Prompts struct {
+	// Instructions are appended to every question, e.g. tone or disclaimers.
+	Instructions string `yaml:"instructions" env:"PROMPT_INSTRUCTIONS"`
+}
+
+// XMCP holds the X MCP server settings.
+type XMCP struct {
+	Port string `yaml:"port" env:"XMCP_PORT,PORT"`
+}
+
+// CGProxy holds the CoinGecko MCP proxy settings.
+type CGProxy struct {
+	Port string `yaml:"port" env:"CGPROXY_PORT,PORT"`
+	Cmd  string `yaml:"cmd" env:"CG_MCP_CMD"`
+	// Args is a shell-like argument string for Cmd.
+	Args string `yaml:"args" env:"CG_MCP_ARGS"`
+}
+
+// Enabled reports whether mentions are answered by the agent rather than the
+// legacy MCP_CMD flow.
+func (a Agent) Enabled() bool {
+	return a.Mode == "inprocess" || a.Cmd != ""
+}
+
+// Default returns the built-in settings.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:            "8080",
+			Workers:         4,
+			QueueSize:       100,
+			JobTimeout:      5 * time.Minute,
+			ShutdownTimeout: 25 * time.Second,
+			ReadyTimeout:    5 * time.Second,
+			StorePath:       "data/bot.db",
+			AuditRetention:  30 * 24 * time.Hour,
+		},
+		Webhook: Webhook{MaxSkew: 5 * time.Minute},
+		X: X{
+			Base:               "https://api.twitter.com/2",
+			AuthMode:           "bearer",
+			Handle:             "NexArb_",
+			ThreadContextDepth: 4,
+		},
+		Agent:  Agent{Mode: "exec"},
+		LLM:    LLM{BaseURL: "https://api.openai.com/v1", Model: "gpt-4.1-mini"},
+		Legacy: Legacy{MCPTool: "coingecko.answer"},
+		Entities: Entities{
+			APIBase: "https://api.coingecko.com/api/v3",
+			Cache:   "data/coins.json",
+			TTL:     24 * time.Hour,
+		},
+		Stale: Stale{Mode: "off", After: 10 * time.Minute},
+		Retry: Retry{
+			MaxAttempts: 5,
+			Interval:    30 * time.Second,
+			Backoff:     time.Minute,
+			MaxBackoff:  30 * time.Minute,
+			Window:      2 * time.Hour,
+		},
+		Policy: Policy{
+			PostingMode: "auto",
+			ReplyPoster: "agent",
+			Authors:     Authors{RateWindow: time.Hour},
+		},
+		XMCP:    XMCP{Port: "8081"},
+		CGProxy: CGProxy{Port: "8082"},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file at path (if
+// not empty) and the environment, and validates it. Unknown keys in the file
+// are errors.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("WORKERS", "8")
+	t.Setenv("JOB_TIMEOUT", "90s")
+	t.Setenv("THREAD_CONTEXT_X", "on")
+	t.Setenv("WEBHOOK_HMAC_SECRETS", "new, ,old")
+	t.Setenv("CG_MCP_HTTP", "http://fallback")
+	t.Setenv("AGENT_X_MCP_HTTP", "http://x")
+	t.Setenv("X_MCP_HTTP", "http://ignored")
+	t.Setenv("CLASSIFY_REPLY_GREETING", "gm!")
+
+	c := Default()
+	if err := c.applyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Workers != 8 || c.Server.JobTimeout != 90*time.Second || !c.X.ThreadContext {
+		t.Errorf("server/x = %+v %+v", c.Server, c.X)
+	}
+	if got := strings.Join(c.Webhook.HMACSecrets, "|"); got != "new|old" {
+		t.Errorf("hmac_secrets = %q", got)
+	}
+	if c.Agent.CGMCPHTTP != "http://fallback" || c.Agent.XMCPHTTP != "http://x" {
+		t.Errorf("agent = %+v, want the first variable that is set", c.Agent)
+	}
+	if c.Classify.Replies["greeting"] != "gm!" {
+		t.Errorf("replies = %v", c.Classify.Replies)
+	}
+}
+
+func TestDefaultsLeaveOptionalStagesOff(t *testing.T) {
+	c := Default()
+	if c.Classify.Enabled {
+		t.Error("classify is on by default")
+	}
+	if c.X.ThreadContext {
+		t.Error("thread context is on by default")
+	}
+	if c.Entities.Enabled {
+		t.Error("entities are on by default")
+	}
+	if c.Stale.Mode != "off" {
+		t.Errorf("stale mode = %q by default, want off", c.Stale.Mode)
+	}
+}
+
+func TestApplyEnvInvalid(t *testing.T) {
+	t.Setenv("WORKERS", "many")
+	t.Setenv("RETRY_INTERVAL", "30")
+	t.Setenv("ENTITIES", "maybe")
+
+	err := Default().applyEnv()
+	if err == nil {
+		t.Fatal("applyEnv accepted invalid values")
+	}
+	for _, want := range []string{"WORKERS (server.workers)", "RETRY_INTERVAL (retry.interval)", "ENTITIES (entities.enabled)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %s", err, want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   string // empty when valid
+	}{
+		{"defaults", func(*Config) {}, ""},
+		{"no workers", func(c *Config) { c.Server.Workers = 0 }, "server.workers"},
+		{"negative timeout", func(c *Config) { c.Server.JobTimeout = -time.Second }, "server.job_timeout"},
+		{"unknown mode", func(c *Config) { c.Agent.Mode = "remote" }, "agent.mode"},
+		{"unknown posting mode", func(c *Config) { c.Policy.PostingMode = "manual" }, "policy.posting_mode"},
+		{"rate limit without window", func(c *Config) {
+			c.Policy.Authors.RateLimit = 3
+			c.Policy.Authors.RateWindow = 0
+		}, "policy.authors.rate_window"},
+		{"zero retry interval", func(c *Config) { c.Retry.Interval = 0 }, "retry.interval"},
+		{"zero retry interval without retries", func(c *Config) {
+			c.Retry.Interval = 0
+			c.Retry.MaxAttempts = 1
+		}, ""},
+		{"zero entities ttl", func(c *Config) {
+			c.Entities.TTL = 0
+			c.Entities.Enabled = true
+		}, "entities.ttl"},
+		{"zero entities ttl when disabled", func(c *Config) { c.Entities.TTL = 0 }, ""},
+		{"zero max skew with hmac", func(c *Config) {
+			c.Webhook.MaxSkew = 0
+			c.Webhook.HMACSecrets = []string{"s"}
+		}, "webhook.max_skew"},
+		{"zero max skew without hmac", func(c *Config) { c.Webhook.MaxSkew = 0 }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.modify(c)
+			err := c.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Fatalf("Validate = %v, want nil", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Fatalf("Validate = %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("server:\n  wrokers: 2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "wrokers") {
+		t.Fatalf("Load = %v, want an unknown key error", err)
+	}
+}
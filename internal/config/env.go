@@ -0,0 +1,130 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// replyEnvPrefix marks variables that override one canned reply, e.g.
+// CLASSIFY_REPLY_GREETING.
+const replyEnvPrefix = "CLASSIFY_REPLY_"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv overrides the fields that have an env tag with the first variable
+// in the tag that is set, collecting every invalid value into one error.
+func (c *Config) applyEnv() error {
+	var errs []error
+	walk(reflect.ValueOf(c).Elem(), "", func(f reflect.Value, path, tag string) {
+		for _, name := range strings.Split(tag, ",") {
+			v, ok := os.LookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := set(f, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s (%s): %w", name, path, err))
+			}
+			return
+		}
+	})
+
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		if cat, ok := strings.CutPrefix(k, replyEnvPrefix); ok && cat != "" {
+			if c.Classify.Replies == nil {
+				c.Classify.Replies = make(map[string]string)
+			}
+			c.Classify.Replies[strings.ToLower(cat)] = v
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// walk calls fn for every field with an env tag, with its YAML path.
+func walk(v reflect.Value, prefix string, fn func(f reflect.Value, path, tag string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		path := prefix + name
+		if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+			walk(v.Field(i), path+".", fn)
+			continue
+		}
+		if tag := sf.Tag.Get("env"); tag != "" {
+			fn(v.Field(i), path, tag)
+		}
+	}
+}
+
+// describe names the setting at path with its variables for error messages,
+// e.g. "policy.posting_mode (POSTING_MODE)".
+func describe(path string) string {
+	var env string
+	walk(reflect.ValueOf(&Config{}).Elem(), "", func(_ reflect.Value, p, tag string) {
+		if p == path {
+			env = strings.ReplaceAll(tag, ",", " or ")
+		}
+	})
+	if env == "" {
+		return path
+	}
+	return path + " (" + env + ")"
+}
+
+func set(f reflect.Value, v string) error {
+	switch {
+	case f.Type() == durationType:
+		d, err := time.ParseDuration(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (e.g. 30s, 5m, 2h)", v)
+		}
+		f.SetInt(int64(d))
+	case f.Kind() == reflect.String:
+		f.SetString(v)
+	case f.Kind() == reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", v)
+		}
+		f.SetInt(int64(n))
+	case f.Kind() == reflect.Bool:
+		b, err := parseBool(v)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.String:
+		f.Set(reflect.ValueOf(splitList(v)))
+	default:
+		return fmt.Errorf("unsupported field type %s", f.Type())
+	}
+	return nil
+}
+
+// parseBool accepts on/off as well as the usual true/false spellings.
+func parseBool(v string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "on", "true", "1", "yes":
+		return true, nil
+	case "off", "false", "0", "no", "":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid switch %q (want on or off)", v)
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	out := []string{}
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
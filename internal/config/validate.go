@@ -0,0 +1,192 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cg-mentions-bot/internal/classify"
+	"cg-mentions-bot/internal/policy"
+	"cg-mentions-bot/internal/twitter"
+)
+
+// problems collects validation errors, one line per setting.
+type problems []error
+
+func (p *problems) add(path, format string, args ...any) {
+	*p = append(*p, fmt.Errorf("%s: %s", describe(path), fmt.Sprintf(format, args...)))
+}
+
+func (p *problems) require(path, value, why string) {
+	if strings.TrimSpace(value) == "" {
+		p.add(path, "is required %s", why)
+	}
+}
+
+func (p *problems) nonNegative(path string, d time.Duration) {
+	if d < 0 {
+		p.add(path, "must not be negative, got %s", d)
+	}
+}
+
+func (p *problems) positive(path string, d time.Duration, why string) {
+	if d <= 0 {
+		p.add(path, "must be positive %s, got %s", why, d)
+	}
+}
+
+func (p problems) err() error {
+	if len(p) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration:\n%w", errors.Join(p...))
+}
+
+// Validate checks the values that every binary relies on.
+func (c *Config) Validate() error {
+	var p problems
+	if c.Server.Workers <= 0 {
+		p.add("server.workers", "must be at least 1, got %d", c.Server.Workers)
+	}
+	if c.Server.QueueSize <= 0 {
+		p.add("server.queue_size", "must be at least 1, got %d", c.Server.QueueSize)
+	}
+	p.nonNegative("server.job_timeout", c.Server.JobTimeout)
+	p.nonNegative("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	p.nonNegative("server.ready_timeout", c.Server.ReadyTimeout)
+	p.nonNegative("server.audit_retention", c.Server.AuditRetention)
+	if len(c.Webhook.HMACSecrets) > 0 {
+		p.positive("webhook.max_skew", c.Webhook.MaxSkew, "when webhook.hmac_secrets are set")
+	} else {
+		p.nonNegative("webhook.max_skew", c.Webhook.MaxSkew)
+	}
+	p.nonNegative("x.poll_interval", c.X.PollInterval)
+	if c.Entities.Enabled {
+		p.positive("entities.ttl", c.Entities.TTL, "when entities are enabled")
+	} else {
+		p.nonNegative("entities.ttl", c.Entities.TTL)
+	}
+	p.nonNegative("stale.after", c.Stale.After)
+	p.nonNegative("stale.max_age", c.Stale.MaxAge)
+	if c.Retry.MaxAttempts > 1 {
+		p.positive("retry.interval", c.Retry.Interval, "when retry.max_attempts is above 1")
+	} else {
+		p.nonNegative("retry.interval", c.Retry.Interval)
+	}
+	p.nonNegative("retry.backoff", c.Retry.Backoff)
+	p.nonNegative("retry.max_backoff", c.Retry.MaxBackoff)
+	p.nonNegative("retry.window", c.Retry.Window)
+	switch c.Agent.Mode {
+	case "exec", "inprocess":
+	default:
+		p.add("agent.mode", "must be exec or inprocess, got %q", c.Agent.Mode)
+	}
+	switch c.X.AuthMode {
+	case "bearer", "oauth1":
+	default:
+		p.add("x.auth_mode", "must be bearer or oauth1, got %q", c.X.AuthMode)
+	}
+	if _, err := policy.ParseStaleMode(c.Stale.Mode); err != nil {
+		p.add("stale.mode", "%v", err)
+	}
+	if _, err := c.Classify.BuildPolicy(); err != nil {
+		p.add("classify.policy", "%v", err)
+	}
+	c.Policy.validate(&p)
+	return p.err()
+}
+
+func (pol *Policy) validate(p *problems) {
+	switch pol.PostingMode {
+	case "auto", "approval":
+	default:
+		p.add("policy.posting_mode", "must be auto or approval, got %q", pol.PostingMode)
+	}
+	switch pol.ReplyPoster {
+	case "agent", "bot":
+	default:
+		p.add("policy.reply_poster", "must be agent or bot, got %q", pol.ReplyPoster)
+	}
+	if pol.Authors.RateLimit < 0 {
+		p.add("policy.authors.rate_limit", "must not be negative, got %d", pol.Authors.RateLimit)
+	}
+	if pol.Authors.RateLimit > 0 && pol.Authors.RateWindow <= 0 {
+		p.add("policy.authors.rate_window", "must be positive when a rate limit is set, got %s", pol.Authors.RateWindow)
+	}
+}
+
+// ValidateBot checks the settings the bot needs on top of Validate.
+func (c *Config) ValidateBot() error {
+	var p problems
+	if !c.Agent.Enabled() {
+		p.require("legacy.mcp_cmd", c.Legacy.MCPCmd, "without agent.cmd (path to CoinGecko MCP server binary)")
+		p.require("x.bearer_token", c.X.BearerToken, "without agent.cmd (user-context token with tweet.write)")
+	}
+	if c.Agent.Mode == "inprocess" {
+		p.require("agent.cg_mcp_http", c.Agent.CGMCPHTTP, "with agent.mode inprocess")
+		p.require("agent.x_mcp_http", c.Agent.XMCPHTTP, "with agent.mode inprocess")
+		p.require("llm.api_key", c.LLM.APIKey, "with agent.mode inprocess")
+	}
+	if c.X.PollInterval > 0 {
+		p.require("x.user_id", c.X.UserID, "when x.poll_interval is set")
+		p.require("x.bearer_token", c.X.BearerToken, "when x.poll_interval is set")
+	}
+	if c.X.ActivityWebhookPath != "" {
+		p.require("x.consumer_secret", c.X.ConsumerSecret, "when x.activity_webhook_path is set")
+	}
+	if c.Policy.ReplyPoster == "bot" && c.Agent.Enabled() && c.Agent.XMCPHTTP == "" && c.ValidatePoster() != nil {
+		p.add("policy.reply_poster", "bot needs agent.x_mcp_http or X credentials to post with")
+	}
+	if c.Policy.PostingMode == "approval" {
+		p.require("server.admin_token", c.Server.AdminToken, "when policy.posting_mode is approval")
+	}
+	if c.Classify.Enabled && c.Classify.LLM {
+		p.require("llm.api_key", c.LLM.APIKey, "when classify.llm is on")
+	}
+	return p.err()
+}
+
+// ValidatePoster checks the X credentials needed to post replies.
+func (c *Config) ValidatePoster() error {
+	var p problems
+	if c.X.AuthMode == "oauth1" {
+		p.require("x.consumer_key", c.X.ConsumerKey, "with x.auth_mode oauth1")
+		p.require("x.consumer_secret", c.X.ConsumerSecret, "with x.auth_mode oauth1")
+		p.require("x.access_token", c.X.AccessToken, "with x.auth_mode oauth1")
+		p.require("x.access_secret", c.X.AccessSecret, "with x.auth_mode oauth1")
+	} else {
+		p.require("x.bearer_token", c.X.BearerToken, "(user-context token with tweet.write)")
+	}
+	return p.err()
+}
+
+// PosterAuth returns the OAuth1 credentials for twitter.NewPoster, or nil in
+// bearer mode.
+func (x X) PosterAuth() *twitter.OAuth1 {
+	if x.AuthMode != "oauth1" {
+		return nil
+	}
+	return &twitter.OAuth1{
+		ConsumerKey:    x.ConsumerKey,
+		ConsumerSecret: x.ConsumerSecret,
+		AccessToken:    x.AccessToken,
+		AccessSecret:   x.AccessSecret,
+	}
+}
+
+// BuildPolicy returns the classifier policy: the defaults with Replies and
+// the Policy overrides ("greeting=canned,off_topic=ignore") applied.
+func (c Classify) BuildPolicy() (classify.Policy, error) {
+	pol := classify.DefaultPolicy()
+	for name, reply := range c.Replies {
+		cat, ok := classify.Parse(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown category %q in replies", name)
+		}
+		r := pol[cat]
+		r.Reply = reply
+		pol[cat] = r
+	}
+	return classify.ParsePolicy(pol, c.Policy)
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Watch reloads the configuration on SIGHUP and, when path is set, whenever the
+// file's modification time changes (checked every interval). Each reload runs
+// Load and ValidateBot again, so environment variables keep overriding the
+// file; apply receives every configuration that passes. A failed reload is
+// reported through logf and the running settings stay in effect. Watch returns
+// when ctx is done.
+func Watch(ctx context.Context, path string, interval time.Duration, logf func(string, ...any), apply func(*Config)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	var mtime time.Time
+	if path != "" && interval > 0 {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		tick = t.C
+		mtime = modTime(path)
+	}
+
+	reload := func(why string) {
+		cfg, err := Load(path)
+		if err == nil {
+			err = cfg.ValidateBot()
+		}
+		if err != nil {
+			logf("config: reload on %s failed, keeping current settings: %v", why, err)
+			return
+		}
+		logf("config: reloaded on %s", why)
+		apply(cfg)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reload("SIGHUP")
+		case <-tick:
+			if m := modTime(path); !m.IsZero() && !m.Equal(mtime) {
+				mtime = m
+				reload("file change")
+			}
+		}
+	}
+}
+
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
@@ -0,0 +1,22 @@
+// Package dashboard serves the operator dashboard, a single static page
+// embedded in the bot binary. The page reads the admin API with the admin
+// token the operator enters, so it holds no data itself.
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard files; mount it with the route prefix stripped.
+func Handler() http.Handler {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	return http.FileServer(http.FS(sub))
+}
@@ -0,0 +1,154 @@
+// Dashboard for cg-mentions-bot. Reads /admin/mentions and /jobs every few
+// seconds and sends the ask box to /admin/ask (a dry run).
+"use strict";
+
+const REFRESH_MS = 5000;
+const $ = (id) => document.getElementById(id);
+let token = localStorage.getItem("cgbot.token") || "";
+let open = new Set();
+
+$("token").value = token;
+$("auth").addEventListener("submit", (e) => {
+  e.preventDefault();
+  token = $("token").value.trim();
+  localStorage.setItem("cgbot.token", token);
+  refresh();
+});
+$("status").addEventListener("change", refresh);
+$("ask").addEventListener("submit", ask);
+
+async function api(path, opts = {}) {
+  const res = await fetch(path, {
+    ...opts,
+    headers: { "Authorization": "Bearer " + token, "Content-Type": "application/json" },
+  });
+  if (!res.ok) {
+    throw new Error(res.status + " " + (await res.text()).trim());
+  }
+  return res.json();
+}
+
+// el builds an element; children are nodes or strings (always set as text).
+function el(tag, attrs = {}, ...children) {
+  const n = document.createElement(tag);
+  for (const [k, v] of Object.entries(attrs)) {
+    if (k === "class") n.className = v;
+    else n.setAttribute(k, v);
+  }
+  for (const c of children) {
+    if (c !== null && c !== undefined) n.append(c);
+  }
+  return n;
+}
+
+function badge(outcome, reason) {
+  return el("span", { class: "badge " + outcome }, reason ? outcome + ": " + reason : outcome);
+}
+
+function ms(v) {
+  if (v === undefined || v === null) return "";
+  return v >= 1000 ? (v / 1000).toFixed(1) + "s" : v + "ms";
+}
+
+async function refresh() {
+  if (!token) {
+    showError("Enter the admin token to load mentions.");
+    return;
+  }
+  try {
+    const status = $("status").value;
+    const data = await api("/admin/mentions?limit=100" + (status ? "&status=" + status : ""));
+    renderMentions(data.mentions || []);
+    showError("");
+    $("updated").textContent = "updated " + new Date().toLocaleTimeString();
+  } catch (err) {
+    showError(err.message);
+  }
+  try {
+    const jobs = await api("/jobs");
+    const n = (s) => (jobs.jobs || []).filter((j) => j.status === s).length;
+    $("queue").textContent = "queue: " + n("running") + " running, " + n("queued") + " queued";
+  } catch {
+    $("queue").textContent = "";
+  }
+}
+
+function showError(msg) {
+  $("error").hidden = !msg;
+  $("error").textContent = msg;
+}
+
+function renderMentions(entries) {
+  const body = $("mentions");
+  body.replaceChildren();
+  for (const e of entries) {
+    const last = e.attempts[e.attempts.length - 1] || {};
+    const row = el("tr", {},
+      el("td", {}, new Date(e.updated_at).toLocaleString()),
+      el("td", {}, e.author_username ? "@" + e.author_username : ""),
+      el("td", { class: "clip" }, e.normalized_text),
+      el("td", {}, badge(last.outcome, last.reason)),
+      el("td", { class: "clip" }, last.answer || last.error || ""),
+      el("td", {}, ms(last.duration_ms)),
+      el("td", {}, (last.tools || []).join(", ")),
+    );
+    row.addEventListener("click", () => {
+      open.has(e.tweet_id) ? open.delete(e.tweet_id) : open.add(e.tweet_id);
+      renderMentions(entries);
+    });
+    body.append(row);
+    if (open.has(e.tweet_id)) {
+      body.append(el("tr", { class: "detail" }, el("td", { colspan: "7" }, attempts(e))));
+    }
+  }
+}
+
+function attempts(e) {
+  const list = el("ol");
+  for (const a of e.attempts) {
+    list.append(el("li", {},
+      new Date(a.at).toLocaleString() + " · " + a.action + " · ", badge(a.outcome, a.reason),
+      " · " + ms(a.duration_ms) + (a.trace_id ? " · trace " + a.trace_id : ""),
+      a.question ? el("pre", {}, "Q: " + a.question) : null,
+      a.answer ? el("pre", {}, "A: " + a.answer) : null,
+      a.error ? el("pre", { class: "error" }, a.error) : null,
+    ));
+  }
+  return el("div", {}, el("div", { class: "muted" }, "tweet " + e.tweet_id + ": " + e.mention.text), list);
+}
+
+async function ask(ev) {
+  ev.preventDefault();
+  const question = $("question").value.trim();
+  if (!question) return;
+  const out = $("answer");
+  out.hidden = false;
+  out.replaceChildren(el("p", { class: "muted" }, "Running…"));
+  $("ask-btn").disabled = true;
+  try {
+    const r = await api("/admin/ask", { method: "POST", body: JSON.stringify({ question }) });
+    const meta = [r.category, r.lang, (r.coins || []).join(", "), ms(r.duration_ms), r.trace_id && "trace " + r.trace_id]
+      .filter(Boolean).join(" · ");
+    const steps = el("ol", { class: "steps" });
+    for (const s of r.steps || []) {
+      steps.append(el("li", {},
+        el("strong", {}, s.tool), " ", el("code", {}, s.input || ""),
+        s.observation ? el("pre", {}, s.observation) : null,
+      ));
+    }
+    out.replaceChildren(
+      el("p", { class: "muted" }, meta),
+      r.skipped ? el("p", {}, badge("skipped", r.skipped)) : null,
+      r.error ? el("pre", { class: "error" }, r.error) : null,
+      r.draft ? el("pre", {}, r.draft) : null,
+      (r.steps || []).length ? el("div", {}, el("h3", {}, "Intermediate steps"), steps) : null,
+    );
+  } catch (err) {
+    out.replaceChildren(el("p", { class: "error" }, err.message));
+  } finally {
+    $("ask-btn").disabled = false;
+  }
+}
+
+refresh();
+setInterval(refresh, REFRESH_MS);
@@ -0,0 +1,238 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"cg-mentions-bot/internal/metrics"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// rankedPages is how many pages of 250 coins by market cap get a rank.
+const rankedPages = 4
+
+// Coin is one entry of CoinGecko's coins list.
+type Coin struct {
+	ID     string `json:"id"`
+	Symbol string `json:"symbol"`
+	Name   string `json:"name"`
+	// Rank is the market cap rank; 0 means unranked.
+	Rank int `json:"rank,omitempty"`
+}
+
+type cacheFile struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Coins     []Coin    `json:"coins"`
+}
+
+// Catalog is a locally cached CoinGecko coins list indexed by symbol and name.
+type Catalog struct {
+	BaseURL   string
+	APIKey    string
+	CachePath string
+	TTL       time.Duration
+
+	client *retryablehttp.Client
+
+	mu        sync.RWMutex
+	fetchedAt time.Time
+	bySymbol  map[string][]Coin
+	byName    map[string][]Coin
+	maxWords  int
+}
+
+// NewCatalog returns a Catalog backed by the CoinGecko API at baseURL and a
+// JSON cache file. Call Load (or Run) before resolving.
+func NewCatalog(baseURL, apiKey, cachePath string, ttl time.Duration) *Catalog {
+	client := retryablehttp.NewClient()
+	client.Logger = nil
+	client.RetryMax = 3
+	return &Catalog{BaseURL: strings.TrimRight(baseURL, "/"), APIKey: apiKey, CachePath: cachePath, TTL: ttl, client: client}
+}
+
+// Ready reports whether coins are loaded.
+func (c *Catalog) Ready() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.bySymbol != nil
+}
+
+// Load reads the cache file and refreshes it from CoinGecko when it is missing
+// or older than TTL. A stale cache is still used if the refresh fails.
+func (c *Catalog) Load(ctx context.Context) error {
+	var cached cacheFile
+	if b, err := os.ReadFile(c.CachePath); err == nil && json.Unmarshal(b, &cached) == nil && len(cached.Coins) > 0 {
+		c.index(cached)
+		if time.Since(cached.FetchedAt) < c.TTL {
+			return nil
+		}
+	}
+
+	fresh, err := c.fetch(ctx)
+	if err != nil {
+		metrics.UpstreamErrors.WithLabelValues("coingecko_api").Inc()
+		if c.Ready() {
+			return fmt.Errorf("refresh coins list (using cache from %s): %w", cached.FetchedAt.Format(time.RFC3339), err)
+		}
+		return fmt.Errorf("fetch coins list: %w", err)
+	}
+	c.index(fresh)
+	if b, err := json.Marshal(fresh); err == nil {
+		if dir := filepath.Dir(c.CachePath); dir != "." {
+			_ = os.MkdirAll(dir, 0o755)
+		}
+		if err := os.WriteFile(c.CachePath, b, 0o644); err != nil {
+			return fmt.Errorf("write coins cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// retryBackoff is the wait after a failed load; it doubles with every further
+// failure, up to TTL.
+const retryBackoff = time.Minute
+
+// Run loads the catalog now and refreshes it whenever the loaded list turns
+// TTL old, until ctx is cancelled. Failed loads are retried with backoff.
+func (c *Catalog) Run(ctx context.Context, logf func(format string, args ...any)) {
+	backoff := retryBackoff
+	for {
+		var wait time.Duration
+		if err := c.Load(ctx); err != nil {
+			logf("entities: %v", err)
+			wait, backoff = backoff, c.nextBackoff(backoff)
+		} else {
+			wait, backoff = c.untilStale(time.Now()), retryBackoff
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// untilStale returns how long the loaded list is still fresh at now.
+func (c *Catalog) untilStale(now time.Time) time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return max(c.TTL-now.Sub(c.fetchedAt), 0)
+}
+
+// nextBackoff doubles d, capped at TTL (but never below retryBackoff).
+func (c *Catalog) nextBackoff(d time.Duration) time.Duration {
+	return min(2*d, max(c.TTL, retryBackoff))
+}
+
+func (c *Catalog) fetch(ctx context.Context) (cacheFile, error) {
+	var coins []Coin
+	if err := c.get(ctx, "/coins/list", nil, &coins); err != nil {
+		return cacheFile{}, err
+	}
+	ranks := make(map[string]int)
+	for page := 1; page <= rankedPages; page++ {
+		var markets []struct {
+			ID   string `json:"id"`
+			Rank int    `json:"market_cap_rank"`
+		}
+		q := url.Values{}
+		q.Set("vs_currency", "usd")
+		q.Set("order", "market_cap_desc")
+		q.Set("per_page", "250")
+		q.Set("page", fmt.Sprint(page))
+		if err := c.get(ctx, "/coins/markets", q, &markets); err != nil {
+			return cacheFile{}, err
+		}
+		for _, m := range markets {
+			ranks[m.ID] = m.Rank
+		}
+	}
+	for i := range coins {
+		coins[i].Rank = ranks[coins[i].ID]
+	}
+	return cacheFile{FetchedAt: time.Now().UTC(), Coins: coins}, nil
+}
+
+func (c *Catalog) get(ctx context.Context, path string, q url.Values, out any) error {
+	u := c.BaseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.APIKey != "" {
+		if strings.Contains(c.BaseURL, "pro-api") {
+			req.Header.Set("x-cg-pro-api-key", c.APIKey)
+		} else {
+			req.Header.Set("x-cg-demo-api-key", c.APIKey)
+		}
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("coingecko %s: status %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Catalog) index(f cacheFile) {
+	bySymbol := make(map[string][]Coin)
+	byName := make(map[string][]Coin)
+	maxWords := 1
+	for _, coin := range f.Coins {
+		s := strings.ToLower(coin.Symbol)
+		bySymbol[s] = append(bySymbol[s], coin)
+		n := strings.ToLower(coin.Name)
+		byName[n] = append(byName[n], coin)
+		if w := len(strings.Fields(n)); w > maxWords && w <= 4 {
+			maxWords = w
+		}
+	}
+	for _, m := range []map[string][]Coin{bySymbol, byName} {
+		for k := range m {
+			sort.SliceStable(m[k], func(a, b int) bool { return better(m[k][a], m[k][b]) })
+		}
+	}
+	c.mu.Lock()
+	c.fetchedAt, c.bySymbol, c.byName, c.maxWords = f.FetchedAt, bySymbol, byName, maxWords
+	c.mu.Unlock()
+}
+
+// better orders coins by market cap rank, unranked last.
+func better(a, b Coin) bool {
+	switch {
+	case a.Rank == 0:
+		return false
+	case b.Rank == 0:
+		return true
+	}
+	return a.Rank < b.Rank
+}
+
+func (c *Catalog) symbol(s string) []Coin {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.bySymbol[strings.ToLower(s)]
+}
+
+func (c *Catalog) name(s string) []Coin {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.byName[strings.ToLower(s)]
+}
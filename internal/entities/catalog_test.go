@@ -0,0 +1,57 @@
+package entities
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadUsesFreshCache(t *testing.T) {
+	hits := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.Error(w, "down", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "coins.json")
+	fetched := time.Now().Add(-20 * time.Hour).UTC()
+	b, _ := json.Marshal(cacheFile{FetchedAt: fetched, Coins: []Coin{{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", Rank: 1}}})
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewCatalog(srv.URL, "", path, 24*time.Hour)
+	if err := c.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if hits != 0 || !c.Ready() {
+		t.Fatalf("Load hit CoinGecko %d times with a fresh cache", hits)
+	}
+	// The next refresh is due when the cache turns 24h old, not a full TTL from now.
+	if got, want := c.untilStale(fetched.Add(20*time.Hour)), 4*time.Hour; got != want {
+		t.Errorf("untilStale = %v, want %v", got, want)
+	}
+	if got := c.untilStale(fetched.Add(30 * time.Hour)); got != 0 {
+		t.Errorf("untilStale past the TTL = %v, want 0", got)
+	}
+}
+
+func TestNextBackoff(t *testing.T) {
+	c := NewCatalog("", "", "", 5*time.Minute)
+	want := []time.Duration{2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	d := retryBackoff
+	for i, w := range want {
+		if d = c.nextBackoff(d); d != w {
+			t.Errorf("backoff %d = %v, want %v", i+1, d, w)
+		}
+	}
+	if got := NewCatalog("", "", "", time.Second).nextBackoff(retryBackoff); got != retryBackoff {
+		t.Errorf("backoff with a short TTL = %v, want %v", got, retryBackoff)
+	}
+}
@@ -0,0 +1,278 @@
+package entities
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Kind tells coins and fiat currencies apart.
+type Kind string
+
+const (
+	KindCoin Kind = "coin"
+	KindFiat Kind = "fiat"
+)
+
+// Entity is a coin or fiat currency mentioned in a tweet.
+type Entity struct {
+	Kind Kind `json:"kind"`
+	// Text is the entity as written, e.g. "$SOL" or "ethereum".
+	Text string `json:"text"`
+	// ID is the CoinGecko coin ID, or the lower-case currency code for fiat.
+	ID     string `json:"id"`
+	Symbol string `json:"symbol,omitempty"`
+	Name   string `json:"name,omitempty"`
+	// Alternatives are other coin IDs sharing the symbol or name; ID is the one
+	// with the highest market cap.
+	Alternatives []string `json:"alternatives,omitempty"`
+}
+
+// maxAlternatives bounds how many other candidates are listed for an ambiguous symbol.
+const maxAlternatives = 3
+
+// bareRankLimit is the market cap rank a coin needs for its symbol or name to
+// be recognized without a "$" prefix. Below it too many English words collide.
+const bareRankLimit = 300
+
+var (
+	cashtagRe = regexp.MustCompile(`\$([A-Za-z][A-Za-z0-9]{0,9})\b`)
+	// priceRe matches currency signs next to an amount, e.g. "$100", "€5" or "100₺".
+	priceRe = regexp.MustCompile(`(R\$|US\$|\$|€|£|₺|¥|₹|₩)\s?\d|\d\s?(€|£|₺|¥|₹|₩)`)
+	wordRe  = regexp.MustCompile(`[\p{L}\p{N}]+(?:[.\-][\p{L}\p{N}]+)*`)
+)
+
+// fiatCodes are the vs_currencies hinted to the agent.
+var fiatCodes = map[string]string{
+	"usd": "US dollar", "eur": "euro", "gbp": "British pound", "jpy": "Japanese yen",
+	"try": "Turkish lira", "brl": "Brazilian real", "inr": "Indian rupee", "cny": "Chinese yuan",
+	"krw": "South Korean won", "cad": "Canadian dollar", "aud": "Australian dollar",
+	"chf": "Swiss franc", "rub": "Russian ruble", "mxn": "Mexican peso", "ars": "Argentine peso",
+}
+
+var fiatSigns = map[string]string{"$": "usd", "US$": "usd", "R$": "brl", "€": "eur", "£": "gbp", "₺": "try", "¥": "jpy", "₹": "inr", "₩": "krw"}
+
+// fiatWords are spelled-out currencies in the languages we detect.
+var fiatWords = map[string]string{
+	"dollar": "usd", "dollars": "usd", "dolar": "usd", "dólar": "usd", "dólares": "usd",
+	"euro": "eur", "euros": "eur",
+	"lira": "try", "tl": "try",
+	"reais": "brl",
+}
+
+// commonWords are lower-case words that are also tickers or coin names of
+// ranked coins ("ONE", "Just", "Pump") but are almost never meant as such.
+var commonWords = setOf(
+	"a", "ai", "all", "am", "an", "and", "any", "are", "as", "at", "be", "best", "bit", "but", "buy", "by",
+	"can", "coin", "cat", "dog", "do", "for", "from", "gas", "get", "go", "gm", "good", "has", "have", "hi",
+	"high", "hold", "how", "i", "if", "in", "is", "it", "just", "key", "like", "low", "max", "me", "meme",
+	"more", "my", "new", "next", "no", "not", "now", "of", "ok", "on", "one", "or", "out", "price", "pump",
+	"real", "sell", "so", "sun", "the", "this", "to", "today", "token", "top", "try", "up", "us", "vs",
+	"was", "we", "what", "when", "why", "will", "with", "you", "your",
+	"de", "el", "en", "la", "para", "por", "que", "ve", "bu", "ne", "mi", "o",
+)
+
+// upperNoise are upper-case words that look like tickers but usually are not.
+var upperNoise = setOf("A", "I", "AI", "ATH", "ATL", "CEO", "DM", "ETF", "FYI", "GM", "OK", "TL", "US", "USA", "UK", "EU", "TVL", "NFT", "DEX", "CEX")
+
+func setOf(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+type span struct {
+	text       string
+	start, end int
+}
+
+// Extract finds coins and fiat currencies in text, in order of appearance and
+// without duplicates. Cashtags resolve against every coin; bare tickers and
+// names only against coins ranked by market cap, to avoid matching ordinary
+// words. Ambiguous symbols resolve to the coin with the highest market cap.
+func (c *Catalog) Extract(text string) []Entity {
+	if !c.Ready() {
+		return nil
+	}
+	type hit struct {
+		pos int
+		Entity
+	}
+	var hits []hit
+	add := func(pos int, e Entity) { hits = append(hits, hit{pos, e}) }
+
+	taken := make([]bool, len(text)+1)
+	for _, m := range cashtagRe.FindAllStringSubmatchIndex(text, -1) {
+		mark(taken, m[0], m[1])
+		tag, sym := text[m[0]:m[1]], text[m[2]:m[3]]
+		if code := strings.ToLower(sym); fiatCodes[code] != "" {
+			add(m[0], fiat(tag, code))
+			continue
+		}
+		if e, ok := coinEntity(tag, c.symbol(sym), 0); ok {
+			add(m[0], e)
+		}
+	}
+	for _, m := range priceRe.FindAllStringSubmatchIndex(text, -1) {
+		sign := m[2:4]
+		if sign[0] < 0 {
+			sign = m[4:6]
+		}
+		add(m[0], fiat(text[sign[0]:sign[1]], fiatSigns[text[sign[0]:sign[1]]]))
+	}
+
+	words := spans(text)
+	c.mu.RLock()
+	maxWords := c.maxWords
+	c.mu.RUnlock()
+	for i := 0; i < len(words); i++ {
+		if taken[words[i].start] {
+			continue
+		}
+		// Longest name first, so "Shiba Inu" wins over "Shiba".
+		n := maxWords
+		for ; n >= 1; n-- {
+			if i+n > len(words) {
+				continue
+			}
+			lower := strings.ToLower(join(text, words[i:i+n]))
+			if lower == "" || n == 1 && commonWords[lower] {
+				continue
+			}
+			if e, ok := coinEntity(join(text, words[i:i+n]), c.name(lower), bareRankLimit); ok {
+				add(words[i].start, e)
+				break
+			}
+		}
+		if n >= 1 {
+			i += n - 1
+			continue
+		}
+
+		w, pos := words[i].text, words[i].start
+		lower := strings.ToLower(w)
+		switch {
+		case fiatWords[lower] != "":
+			add(pos, fiat(w, fiatWords[lower]))
+		case fiatCodes[lower] != "" && (isUpper(w) || !commonWords[lower]):
+			add(pos, fiat(w, lower))
+		case isUpper(w) && len(w) >= 2 && !upperNoise[w]:
+			if e, ok := coinEntity(w, c.symbol(w), bareRankLimit); ok {
+				add(pos, e)
+			}
+		case !commonWords[lower] && len(w) >= 3:
+			// Lower-case tickers ("btc", "sol") only for the largest coins.
+			if e, ok := coinEntity(w, c.symbol(w), 100); ok {
+				add(pos, e)
+			}
+		}
+	}
+
+	sort.SliceStable(hits, func(a, b int) bool { return hits[a].pos < hits[b].pos })
+	var out []Entity
+	seen := make(map[string]bool)
+	for _, h := range hits {
+		key := string(h.Kind) + ":" + h.ID
+		if h.ID == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, h.Entity)
+	}
+	return out
+}
+
+// coinEntity picks the best of candidates (sorted by rank). With rankLimit > 0
+// the best candidate must be ranked within it.
+func coinEntity(text string, candidates []Coin, rankLimit int) (Entity, bool) {
+	if len(candidates) == 0 {
+		return Entity{}, false
+	}
+	best := candidates[0]
+	if rankLimit > 0 && (best.Rank == 0 || best.Rank > rankLimit) {
+		return Entity{}, false
+	}
+	e := Entity{Kind: KindCoin, Text: text, ID: best.ID, Symbol: strings.ToUpper(best.Symbol), Name: best.Name}
+	for _, alt := range candidates[1:] {
+		if len(e.Alternatives) == maxAlternatives {
+			break
+		}
+		e.Alternatives = append(e.Alternatives, alt.ID)
+	}
+	return e, true
+}
+
+func fiat(text, code string) Entity {
+	return Entity{Kind: KindFiat, Text: text, ID: code, Symbol: strings.ToUpper(code), Name: fiatCodes[code]}
+}
+
+func spans(text string) []span {
+	idx := wordRe.FindAllStringIndex(text, -1)
+	out := make([]span, len(idx))
+	for i, m := range idx {
+		out[i] = span{text: text[m[0]:m[1]], start: m[0], end: m[1]}
+	}
+	return out
+}
+
+// join returns the text covered by words when they are separated by single spaces only.
+func join(text string, words []span) string {
+	for i := 1; i < len(words); i++ {
+		if text[words[i-1].end:words[i].start] != " " {
+			return ""
+		}
+	}
+	return text[words[0].start:words[len(words)-1].end]
+}
+
+func mark(taken []bool, start, end int) {
+	for i := start; i < end; i++ {
+		taken[i] = true
+	}
+}
+
+func isUpper(s string) bool {
+	hasLetter := false
+	for _, r := range s {
+		if unicode.IsLower(r) {
+			return false
+		}
+		hasLetter = hasLetter || unicode.IsLetter(r)
+	}
+	return hasLetter
+}
+
+// Hints formats entities as a prompt section for the agent. It returns "" when
+// there is nothing to hint.
+func Hints(ents []Entity) string {
+	var coins, fiats []string
+	for _, e := range ents {
+		switch e.Kind {
+		case KindCoin:
+			s := fmt.Sprintf("%s → %s (%s, %s)", e.Text, e.ID, e.Name, e.Symbol)
+			if len(e.Alternatives) > 0 {
+				s += fmt.Sprintf(" [largest by market cap; also: %s]", strings.Join(e.Alternatives, ", "))
+			}
+			coins = append(coins, s)
+		case KindFiat:
+			fiats = append(fiats, e.ID)
+		}
+	}
+	if len(coins) == 0 && len(fiats) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Resolved entities:")
+	if len(coins) > 0 {
+		b.WriteString("\n- Coins (CoinGecko IDs): " + strings.Join(coins, "; "))
+	}
+	if len(fiats) > 0 {
+		b.WriteString("\n- Fiat (vs_currency): " + strings.Join(fiats, ", "))
+	}
+	b.WriteString("\nUse these IDs when calling CoinGecko tools instead of searching for them.")
+	return b.String()
+}
@@ -0,0 +1,79 @@
+package entities
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testCatalog() *Catalog {
+	c := NewCatalog("http://coingecko.invalid", "", "", 0)
+	c.index(cacheFile{Coins: []Coin{
+		{ID: "bitcoin", Symbol: "btc", Name: "Bitcoin", Rank: 1},
+		{ID: "ethereum", Symbol: "eth", Name: "Ethereum", Rank: 2},
+		{ID: "solana", Symbol: "sol", Name: "Solana", Rank: 5},
+		{ID: "shiba-inu", Symbol: "shib", Name: "Shiba Inu", Rank: 15},
+		{ID: "pepe", Symbol: "pepe", Name: "Pepe", Rank: 30},
+		{ID: "harmony", Symbol: "one", Name: "Harmony", Rank: 250},
+		{ID: "sol-wormhole", Symbol: "sol", Name: "Sol (Wormhole)", Rank: 900},
+		{ID: "bitcoin-bep2", Symbol: "btc", Name: "Bitcoin BEP2"},
+		{ID: "tiny-coin", Symbol: "tiny", Name: "Tiny"},
+	}})
+	return c
+}
+
+// ids lists entities as kind:id for compact comparisons.
+func ids(ents []Entity) []string {
+	var out []string
+	for _, e := range ents {
+		out = append(out, string(e.Kind)+":"+e.ID)
+	}
+	return out
+}
+
+func TestExtract(t *testing.T) {
+	c := testCatalog()
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"what is $BTC in EUR?", []string{"coin:bitcoin", "fiat:eur"}},
+		{"ethereum vs sol, and $btc again BTC", []string{"coin:ethereum", "coin:solana", "coin:bitcoin"}},
+		{"Shiba Inu price in dólares", []string{"coin:shiba-inu", "fiat:usd"}},
+		{"will PEPE hit €1?", []string{"coin:pepe", "fiat:eur"}},
+		{"btc fiyatı kaç lira, 100₺ ne eder", []string{"coin:bitcoin", "fiat:try"}},
+		// Common words and unranked coins need a cashtag.
+		{"just one more try", nil},
+		{"is tiny a good coin? $TINY", []string{"coin:tiny-coin"}},
+		{"gm ser, any ATH today?", nil},
+	}
+	for _, tt := range tests {
+		if got := ids(c.Extract(tt.text)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Extract(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestExtractAmbiguousSymbol(t *testing.T) {
+	ents := testCatalog().Extract("$SOL")
+	if len(ents) != 1 {
+		t.Fatalf("Extract = %+v", ents)
+	}
+	e := ents[0]
+	if e.ID != "solana" || e.Symbol != "SOL" || e.Text != "$SOL" || !reflect.DeepEqual(e.Alternatives, []string{"sol-wormhole"}) {
+		t.Fatalf("Extract($SOL) = %+v, want solana with sol-wormhole as alternative", e)
+	}
+	hints := Hints(ents)
+	if !strings.Contains(hints, "$SOL → solana (Solana, SOL) [largest by market cap; also: sol-wormhole]") {
+		t.Fatalf("Hints = %q", hints)
+	}
+}
+
+func TestExtractNotReady(t *testing.T) {
+	if got := NewCatalog("", "", "", 0).Extract("$BTC"); got != nil {
+		t.Fatalf("Extract before Load = %v, want nil", got)
+	}
+	if got := Hints(nil); got != "" {
+		t.Fatalf("Hints(nil) = %q", got)
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cg-mentions-bot/internal/metrics"
+	"cg-mentions-bot/internal/types"
+	"cg-mentions-bot/internal/webhook"
+)
+
+// ActivityHandler implements X's Account Activity API webhook: it answers CRC
+// challenges and turns tweet_create_events that mention us into mentions.
+type ActivityHandler struct {
+	// ConsumerSecret is the X app consumer secret used for CRC and signatures.
+	ConsumerSecret string
+	// UserID and Handle identify our account (Handle without the @).
+	UserID   string
+	Handle   string
+	Mentions MentionsHandler
+}
+
+type activityTweet struct {
+	IDStr                string `json:"id_str"`
+	Text                 string `json:"text"`
+	CreatedAt            string `json:"created_at"`
+	Lang                 string `json:"lang"`
+	InReplyToStatusIDStr string `json:"in_reply_to_status_id_str"`
+	InReplyToUserIDStr   string `json:"in_reply_to_user_id_str"`
+	InReplyToScreenName  string `json:"in_reply_to_screen_name"`
+	ExtendedTweet        *struct {
+		FullText string           `json:"full_text"`
+		Entities activityEntities `json:"entities"`
+	} `json:"extended_tweet"`
+	User struct {
+		IDStr      string `json:"id_str"`
+		ScreenName string `json:"screen_name"`
+	} `json:"user"`
+	Entities        activityEntities `json:"entities"`
+	QuotedStatus    *activityTweet   `json:"quoted_status"`
+	RetweetedStatus json.RawMessage  `json:"retweeted_status"`
+	RetweetCount    int              `json:"retweet_count"`
+	ReplyCount      int              `json:"reply_count"`
+	FavoriteCount   int              `json:"favorite_count"`
+	QuoteCount      int              `json:"quote_count"`
+}
+
+// activityEntities are v1.1 entities; Indices are [start, end) rune offsets.
+type activityEntities struct {
+	UserMentions []struct {
+		IDStr      string `json:"id_str"`
+		ScreenName string `json:"screen_name"`
+		Indices    [2]int `json:"indices"`
+	} `json:"user_mentions"`
+	Symbols []struct {
+		Text    string `json:"text"`
+		Indices [2]int `json:"indices"`
+	} `json:"symbols"`
+	Hashtags []struct {
+		Text    string `json:"text"`
+		Indices [2]int `json:"indices"`
+	} `json:"hashtags"`
+	URLs []struct {
+		URL         string `json:"url"`
+		ExpandedURL string `json:"expanded_url"`
+		DisplayURL  string `json:"display_url"`
+		Indices     [2]int `json:"indices"`
+	} `json:"urls"`
+}
+
+// CRC handles GET with ?crc_token=... by returning the HMAC response token.
+func (h ActivityHandler) CRC(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("crc_token")
+	if token == "" {
+		http.Error(w, "missing crc_token", http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"response_token": webhook.CRCResponseToken(h.ConsumerSecret, token),
+	})
+}
+
+// Events handles POSTed account activity and dispatches mentions of our account.
+func (h ActivityHandler) Events(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if err := webhook.VerifyX(h.ConsumerSecret, r.Header.Get(webhook.HeaderXSignature), body); err != nil {
+		http.Error(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var payload struct {
+		ForUserID         string          `json:"for_user_id"`
+		TweetCreateEvents []activityTweet `json:"tweet_create_events"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	mentions := make([]types.Mention, 0, len(payload.TweetCreateEvents))
+	for _, t := range payload.TweetCreateEvents {
+		if h.mentionsUs(t) {
+			mentions = append(mentions, t.toMention())
+		}
+	}
+	if len(mentions) > 0 {
+		metrics.MentionsReceived.WithLabelValues("activity").Add(float64(len(mentions)))
+		if n, err := h.Mentions.Dispatch(r.Context(), mentions); err != nil {
+			log.Printf("activity: dispatched %d/%d mentions: %v", n, len(mentions), err)
+		}
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// mentionsUs reports whether t is someone else's original tweet or reply that tags our account.
+func (h ActivityHandler) mentionsUs(t activityTweet) bool {
+	if len(t.RetweetedStatus) > 0 && string(t.RetweetedStatus) != "null" {
+		return false
+	}
+	if t.User.IDStr == h.UserID || strings.EqualFold(t.User.ScreenName, h.Handle) {
+		return false
+	}
+	for _, m := range t.Entities.UserMentions {
+		if (h.UserID != "" && m.IDStr == h.UserID) || strings.EqualFold(m.ScreenName, h.Handle) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t activityTweet) toMention() types.Mention {
+	text, ents := t.Text, t.Entities
+	if t.ExtendedTweet != nil && t.ExtendedTweet.FullText != "" {
+		text, ents = t.ExtendedTweet.FullText, t.ExtendedTweet.Entities
+	}
+	m := types.Mention{
+		TweetID:        t.IDStr,
+		Text:           text,
+		AuthorID:       t.User.IDStr,
+		AuthorUsername: t.User.ScreenName,
+		Lang:           t.Lang,
+		Entities:       ents.toEntities(),
+		PublicMetrics: &types.PublicMetrics{
+			RetweetCount: t.RetweetCount,
+			ReplyCount:   t.ReplyCount,
+			LikeCount:    t.FavoriteCount,
+			QuoteCount:   t.QuoteCount,
+		},
+	}
+	if ts, err := time.Parse(time.RubyDate, t.CreatedAt); err == nil {
+		m.CreatedAt = ts.UTC()
+	}
+	// Account Activity payloads do not carry conversation_id; a tweet that is
+	// not a reply starts its own conversation.
+	if t.InReplyToStatusIDStr == "" {
+		m.ConversationID = t.IDStr
+	} else {
+		m.ReferencedTweets = append(m.ReferencedTweets, types.ReferencedTweet{
+			Type:           types.RefRepliedTo,
+			ID:             t.InReplyToStatusIDStr,
+			AuthorID:       t.InReplyToUserIDStr,
+			AuthorUsername: t.InReplyToScreenName,
+		})
+	}
+	if q := t.QuotedStatus; q != nil && q.IDStr != "" {
+		quoted := q.Text
+		if q.ExtendedTweet != nil && q.ExtendedTweet.FullText != "" {
+			quoted = q.ExtendedTweet.FullText
+		}
+		m.ReferencedTweets = append(m.ReferencedTweets, types.ReferencedTweet{
+			Type:           types.RefQuoted,
+			ID:             q.IDStr,
+			Text:           quoted,
+			AuthorID:       q.User.IDStr,
+			AuthorUsername: q.User.ScreenName,
+		})
+	}
+	return m
+}
+
+func (e activityEntities) toEntities() *types.Entities {
+	out := &types.Entities{}
+	for _, s := range e.Symbols {
+		out.Cashtags = append(out.Cashtags, types.TagEntity{Start: s.Indices[0], End: s.Indices[1], Tag: s.Text})
+	}
+	for _, h := range e.Hashtags {
+		out.Hashtags = append(out.Hashtags, types.TagEntity{Start: h.Indices[0], End: h.Indices[1], Tag: h.Text})
+	}
+	for _, u := range e.UserMentions {
+		out.Mentions = append(out.Mentions, types.UserEntity{Start: u.Indices[0], End: u.Indices[1], Username: u.ScreenName, ID: u.IDStr})
+	}
+	for _, u := range e.URLs {
+		out.URLs = append(out.URLs, types.URLEntity{Start: u.Indices[0], End: u.Indices[1], URL: u.URL, ExpandedURL: u.ExpandedURL, DisplayURL: u.DisplayURL})
+	}
+	if len(out.Cashtags)+len(out.Hashtags)+len(out.Mentions)+len(out.URLs) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"cg-mentions-bot/internal/jobs"
+	"cg-mentions-bot/internal/types"
+	"cg-mentions-bot/internal/webhook"
+)
+
+// activityFixture is a tweet_create_events delivery for @cgbot (ID 100): our
+// own reply, a reply that does not tag us, a retweet of a mention and one
+// real mention with an extended tweet.
+const activityFixture = `{
+  "for_user_id": "100",
+  "tweet_create_events": [
+    {"id_str": "1", "text": "@alice BTC is $1", "in_reply_to_status_id_str": "9",
+     "user": {"id_str": "100", "screen_name": "cgbot"},
+     "entities": {"user_mentions": [{"id_str": "7", "screen_name": "alice", "indices": [0, 6]}]}},
+    {"id_str": "2", "text": "@bob nice one", "in_reply_to_status_id_str": "1",
+     "user": {"id_str": "8", "screen_name": "carol"},
+     "entities": {"user_mentions": [{"id_str": "9", "screen_name": "bob", "indices": [0, 4]}]}},
+    {"id_str": "3", "text": "RT @dave: @cgbot price of eth?",
+     "user": {"id_str": "10", "screen_name": "erin"},
+     "retweeted_status": {"id_str": "4"},
+     "entities": {"user_mentions": [{"id_str": "100", "screen_name": "cgbot", "indices": [11, 17]}]}},
+    {"id_str": "5", "text": "@CGBot what is the price of $BTC…", "lang": "en",
+     "created_at": "Wed Jan 01 00:02:00 +0000 2025", "in_reply_to_status_id_str": "1",
+     "in_reply_to_user_id_str": "100", "in_reply_to_screen_name": "cgbot",
+     "user": {"id_str": "7", "screen_name": "alice"},
+     "entities": {"user_mentions": [{"id_str": "100", "screen_name": "CGBot", "indices": [0, 6]}]},
+     "extended_tweet": {"full_text": "@CGBot what is the price of $BTC today?",
+       "entities": {"user_mentions": [{"id_str": "100", "screen_name": "CGBot", "indices": [0, 6]}],
+                    "symbols": [{"text": "BTC", "indices": [28, 32]}]}}}
+  ]
+}`
+
+func xSign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestActivityEventsDispatchesOnlyMentionsOfUs(t *testing.T) {
+	var mu sync.Mutex
+	var got []types.Mention
+	q := jobs.NewQueue(1, 10, func(_ context.Context, m types.Mention) types.MentionResult {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, m)
+		return types.MentionResult{Posted: true}
+	})
+	q.Start(context.Background())
+	h := ActivityHandler{ConsumerSecret: "consumer", UserID: "100", Handle: "cgbot", Mentions: MentionsHandler{Queue: q}}
+
+	body := []byte(activityFixture)
+	req := httptest.NewRequest(http.MethodPost, "/x/webhook", bytes.NewReader(body))
+	req.Header.Set(webhook.HeaderXSignature, xSign("consumer", body))
+	w := httptest.NewRecorder()
+	h.Events(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	q.Shutdown(context.Background())
+
+	if len(got) != 1 {
+		t.Fatalf("dispatched %+v, want only tweet 5", got)
+	}
+	m := got[0]
+	if m.TweetID != "5" || m.Text != "@CGBot what is the price of $BTC today?" || m.AuthorUsername != "alice" || m.CreatedAt.IsZero() {
+		t.Errorf("mention = %+v", m)
+	}
+	if m.Entities == nil || len(m.Entities.Cashtags) != 1 || m.Entities.Cashtags[0].Tag != "BTC" {
+		t.Errorf("entities = %+v, want the extended tweet's $BTC", m.Entities)
+	}
+	if len(m.ReferencedTweets) != 1 || m.ReferencedTweets[0].Type != types.RefRepliedTo || m.ReferencedTweets[0].ID != "1" {
+		t.Errorf("referenced tweets = %+v, want a reply to 1", m.ReferencedTweets)
+	}
+}
+
+func TestActivityEventsRejectsBadSignature(t *testing.T) {
+	h := ActivityHandler{ConsumerSecret: "consumer", UserID: "100", Handle: "cgbot"}
+	body := []byte(activityFixture)
+	for name, sig := range map[string]string{"missing": "", "wrong secret": xSign("other", body)} {
+		req := httptest.NewRequest(http.MethodPost, "/x/webhook", bytes.NewReader(body))
+		if sig != "" {
+			req.Header.Set(webhook.HeaderXSignature, sig)
+		}
+		w := httptest.NewRecorder()
+		h.Events(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s signature: status = %d, want 401", name, w.Code)
+		}
+	}
+}
+
+func TestActivityCRC(t *testing.T) {
+	h := ActivityHandler{ConsumerSecret: "secret"}
+	w := httptest.NewRecorder()
+	h.CRC(w, httptest.NewRequest(http.MethodGet, "/x/webhook?crc_token=challenge", nil))
+	var out struct {
+		ResponseToken string `json:"response_token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || out.ResponseToken != "sha256=oeUF6Wxqoezggrue+wbIDxKRPSF6esKwizR2MHh9HaA=" {
+		t.Fatalf("CRC = %d %+v", w.Code, out)
+	}
+}
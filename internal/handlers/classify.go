@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"cg-mentions-bot/internal/classify"
+	"cg-mentions-bot/internal/types"
+)
+
+// SkipIgnored is reported for mentions whose category policy is "ignore".
+const SkipIgnored = "ignored"
+
+var errNoPoster = errors.New("no reply poster configured")
+
+// respond skips mentions the Staleness policy rejects, classifies m (when a
+// Classifier is set) and then answers it, posts the category's canned reply, or
+// ignores it. With draftOnly the reply is drafted but not posted.
+func (h MentionsHandler) respond(ctx context.Context, m types.Mention, draftOnly bool) (string, types.MentionResult) {
+	stale := h.Staleness.Check(m, time.Now())
+	if stale.Skip != "" {
+		return "", types.MentionResult{TweetID: m.TweetID, Skipped: stale.Skip}
+	}
+	if h.Classifier == nil {
+		return h.answer(ctx, m, stale, draftOnly)
+	}
+
+	cat, err := h.Classifier.Classify(ctx, m.Text)
+	if err != nil {
+		log.Printf("mention %s: %v (using %s)", m.TweetID, err, cat)
+	}
+	rule := h.Classifier.Policy.Rule(cat)
+
+	var ans string
+	var res types.MentionResult
+	switch rule.Action {
+	case classify.ActionIgnore:
+		res = types.MentionResult{TweetID: m.TweetID, Skipped: SkipIgnored}
+	case classify.ActionCanned:
+		ans = rule.Reply
+		if draftOnly {
+			res = types.MentionResult{TweetID: m.TweetID, Draft: ans}
+		} else {
+			res = h.post(ctx, m.TweetID, ans)
+		}
+	default:
+		ans, res = h.answer(ctx, m, stale, draftOnly)
+	}
+	res.Category = string(cat)
+	return ans, res
+}
+
+// post replies under tweetID with text using the configured Reply function.
+func (h MentionsHandler) post(ctx context.Context, tweetID, text string) types.MentionResult {
+	if h.Reply == nil {
+		return types.MentionResult{TweetID: tweetID, Error: errNoPoster.Error(), Failure: FailureReply}
+	}
+	id, err := post(ctx, h.Reply, ReplyIn{InReplyTo: tweetID, Text: text})
+	if err != nil {
+		return types.MentionResult{TweetID: tweetID, Error: err.Error(), Failure: FailureReply}
+	}
+	return types.MentionResult{TweetID: tweetID, Posted: true, ReplyID: id}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"cg-mentions-bot/internal/jobs"
+	"cg-mentions-bot/internal/tracing"
+	"cg-mentions-bot/internal/types"
+)
+
+// Preview answers question as a dry run of the full pipeline, as if it had been
+// tweeted just now. The author policy and the Store are bypassed, and the
+// result carries the draft and the agent's intermediate steps.
+func (h MentionsHandler) Preview(ctx context.Context, question string) types.MentionResult {
+	ctx, span := tracing.Start(ctx, "preview")
+	defer span.End()
+	m := types.Mention{
+		TweetID:   "preview-" + jobs.NewBatchID(),
+		Text:      question,
+		CreatedAt: time.Now().UTC(),
+	}
+	ans, res := h.respond(ctx, m, true)
+	res.DryRun = true
+	res.Answer = ans
+	res.TraceID = tracing.TraceID(ctx)
+	return res
+}
+
+// DashboardHandler backs the dashboard's ask box.
+type DashboardHandler struct {
+	Mentions MentionsHandler
+}
+
+// Ask handles POST /admin/ask with {"question":"..."} and returns the dry-run
+// result with its duration.
+func (h DashboardHandler) Ask(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Question string `json:"question"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.Question) == "" {
+		http.Error(w, "bad request: question is required", http.StatusBadRequest)
+		return
+	}
+	start := time.Now()
+	res := h.Mentions.Preview(r.Context(), strings.TrimSpace(in.Question))
+	writeJSON(w, http.StatusOK, struct {
+		types.MentionResult
+		DurationMS int64 `json:"duration_ms"`
+	}{res, time.Since(start).Milliseconds()})
+}
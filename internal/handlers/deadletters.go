@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"cg-mentions-bot/internal/jobs"
+	"cg-mentions-bot/internal/store"
+	"cg-mentions-bot/internal/types"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// deadLetter keeps failed mentions for retries and forgets them once they are
+// posted, drafted or deliberately skipped.
+func (h MentionsHandler) deadLetter(m types.Mention, rec store.Record) {
+	var err error
+	if rec.Status == store.StatusFailed {
+		_, err = h.Store.FailDeadLetter(m, rec.Error)
+	} else {
+		err = h.Store.DeleteDeadLetter(m.TweetID)
+	}
+	if err != nil {
+		log.Printf("store: dead letter %s: %v", m.TweetID, err)
+	}
+}
+
+// DeadLettersHandler exposes failed mentions and manual replays to admins.
+type DeadLettersHandler struct {
+	Store    *store.Store
+	Mentions MentionsHandler
+}
+
+// List handles GET /admin/dead-letters?status=retrying|exhausted.
+func (h DeadLettersHandler) List(w http.ResponseWriter, r *http.Request) {
+	letters, err := h.Store.ListDeadLetters(store.DeadLetterStatus(r.URL.Query().Get("status")))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(letters), "dead_letters": letters})
+}
+
+// Retry handles POST /mentions/{tweet_id}/retry. It replays the dead-lettered
+// mention regardless of attempts and age: queued when a Queue is configured
+// (202 with the job), otherwise inline (200 with the result).
+func (h DeadLettersHandler) Retry(w http.ResponseWriter, r *http.Request) {
+	d, err := h.Store.GetDeadLetter(chi.URLParam(r, "tweet_id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if h.Mentions.Queue != nil {
+		j, err := h.Mentions.Queue.Enqueue(r.Context(), jobs.NewBatchID(), d.Mention)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, http.StatusAccepted, j)
+		return
+	}
+	writeJSON(w, http.StatusOK, h.Mentions.Process(r.Context(), d.Mention))
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"cg-mentions-bot/internal/store"
+	"cg-mentions-bot/internal/types"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var errDraftNotPending = errors.New("draft is not pending")
+
+// saveDraft puts the drafted reply for m into the approval queue.
+func (h MentionsHandler) saveDraft(m types.Mention, res types.MentionResult) types.MentionResult {
+	if h.Store == nil {
+		res.Error = "approval mode requires a store"
+		res.Failure = FailureStore
+		return res
+	}
+	err := h.Store.PutDraft(store.Draft{
+		TweetID:        m.TweetID,
+		ConversationID: m.ConversationID,
+		AuthorUsername: m.AuthorUsername,
+		Question:       normalizeTweetText(m.Text),
+		Text:           res.Draft,
+		Category:       res.Category,
+		Lang:           res.Lang,
+		Tools:          res.Tools,
+	})
+	if err != nil {
+		res.Error = fmt.Sprintf("save draft: %v", err)
+		res.Failure = FailureStore
+		return res
+	}
+	res.PendingApproval = true
+	return res
+}
+
+// DraftsHandler exposes the reply approval queue to operators.
+type DraftsHandler struct {
+	Store *store.Store
+	// Reply posts an approved draft (twitter.NewPoster or twitter.NewMCPPoster).
+	Reply func(ctx context.Context, in ReplyIn) (string, error)
+}
+
+// List handles GET /admin/drafts?status=pending. Without status it lists every draft.
+func (h DraftsHandler) List(w http.ResponseWriter, r *http.Request) {
+	drafts, err := h.Store.ListDrafts(store.DraftStatus(r.URL.Query().Get("status")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Count  int           `json:"count"`
+		Drafts []store.Draft `json:"drafts"`
+	}{Count: len(drafts), Drafts: drafts})
+}
+
+// Get handles GET /admin/drafts/{id}.
+func (h DraftsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	d, err := h.Store.GetDraft(chi.URLParam(r, "id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// Edit handles PUT /admin/drafts/{id} with {"text":"..."} for a pending draft.
+func (h DraftsHandler) Edit(w http.ResponseWriter, r *http.Request) {
+	var in struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || strings.TrimSpace(in.Text) == "" {
+		http.Error(w, "bad request: text is required", http.StatusBadRequest)
+		return
+	}
+	d, err := h.Store.UpdateDraft(chi.URLParam(r, "id"), func(d *store.Draft) error {
+		if d.Status != store.DraftPending {
+			return errDraftNotPending
+		}
+		d.Text = strings.TrimSpace(in.Text)
+		return nil
+	})
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// Approve handles POST /admin/drafts/{id}/approve: it posts the draft text as a
+// reply to the original tweet. If posting fails the draft returns to pending.
+func (h DraftsHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	d, err := h.Store.UpdateDraft(id, func(d *store.Draft) error {
+		if d.Status != store.DraftPending {
+			return errDraftNotPending
+		}
+		d.Status = store.DraftPosting
+		return nil
+	})
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+
+	start := time.Now()
+	replyID, postErr := "", errNoPoster
+	if h.Reply != nil {
+		replyID, postErr = post(r.Context(), h.Reply, ReplyIn{InReplyTo: d.TweetID, Text: d.Text})
+	}
+	attempt := store.AuditAttempt{Action: AuditApprove, Outcome: "posted", Answer: d.Text, Posted: postErr == nil, ReplyID: replyID}
+	if postErr != nil {
+		attempt.Outcome, attempt.Reason, attempt.Error = "failed", FailureReply, postErr.Error()
+	}
+	attempt.DurationMS = time.Since(start).Milliseconds()
+	h.audit(d, attempt)
+	d, err = h.Store.UpdateDraft(id, func(d *store.Draft) error {
+		if postErr != nil {
+			d.Status, d.Error = store.DraftPending, postErr.Error()
+		} else {
+			d.Status, d.Error, d.ReplyID = store.DraftPosted, "", replyID
+		}
+		return nil
+	})
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	if postErr != nil {
+		writeJSON(w, http.StatusBadGateway, d)
+		return
+	}
+	h.finish(d, store.StatusPosted)
+	writeJSON(w, http.StatusOK, d)
+}
+
+// Reject handles POST /admin/drafts/{id}/reject. The tweet is not answered.
+func (h DraftsHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	d, err := h.Store.UpdateDraft(chi.URLParam(r, "id"), func(d *store.Draft) error {
+		if d.Status != store.DraftPending {
+			return errDraftNotPending
+		}
+		d.Status = store.DraftRejected
+		return nil
+	})
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	h.audit(d, store.AuditAttempt{Action: AuditReject, Outcome: "skipped", Reason: SkipRejected, Answer: d.Text})
+	h.finish(d, store.StatusSkipped)
+	writeJSON(w, http.StatusOK, d)
+}
+
+// audit adds the review of d to the mention's audit log.
+func (h DraftsHandler) audit(d store.Draft, a store.AuditAttempt) {
+	m := types.Mention{TweetID: d.TweetID, ConversationID: d.ConversationID, AuthorUsername: d.AuthorUsername}
+	if e, err := h.Store.GetAudit(d.TweetID); err == nil {
+		m = e.Mention
+	}
+	a.Category, a.Lang = d.Category, d.Lang
+	if err := h.Store.AppendAudit(m, d.Question, a); err != nil {
+		log.Printf("store: audit %s: %v", d.TweetID, err)
+	}
+}
+
+// finish records the reviewed outcome in the processed-tweet store.
+func (h DraftsHandler) finish(d store.Draft, status store.Status) {
+	_ = h.Store.Finish(store.Record{
+		TweetID:        d.TweetID,
+		ConversationID: d.ConversationID,
+		AuthorUsername: d.AuthorUsername,
+		Question:       d.Question,
+		Answer:         d.Text,
+		ReplyID:        d.ReplyID,
+		Status:         status,
+	})
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, errDraftNotPending):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cg-mentions-bot/internal/store"
+	"cg-mentions-bot/internal/types"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestApprovalModeDraftsAndApprovePosts(t *testing.T) {
+	ag := &fakeAgent{answer: "BTC is $1"}
+	rp := &fakeReply{}
+	st := openStore(t)
+	h := MentionsHandler{
+		AgentRun: ag.run,
+		Reply:    rp.reply,
+		Store:    st,
+		Settings: NewLiveSettings(Settings{RequireApproval: true}),
+	}
+	m := types.Mention{TweetID: "1", Text: "@bot price of btc?", AuthorUsername: "alice", ConversationID: "c"}
+
+	res := h.Process(context.Background(), m)
+	if !res.PendingApproval || res.Posted {
+		t.Fatalf("result = %+v, want a pending draft", res)
+	}
+	if len(ag.reqs) != 1 || !ag.reqs[0].DryRun {
+		t.Fatalf("agent requests = %+v, want a draft-only run", ag.reqs)
+	}
+	if len(rp.posted) != 0 {
+		t.Fatalf("approval mode replied: %+v", rp.posted)
+	}
+	if d, err := st.GetDraft("1"); err != nil || d.Status != store.DraftPending || d.Text != "BTC is $1" {
+		t.Fatalf("draft = %+v, %v", d, err)
+	}
+	if rec, _, _ := st.Get("1"); rec.Status != store.StatusDrafted {
+		t.Fatalf("record status = %q, want drafted", rec.Status)
+	}
+
+	r := chi.NewRouter()
+	r.Post("/admin/drafts/{id}/approve", DraftsHandler{Store: st, Reply: rp.reply}.Approve)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/drafts/1/approve", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("approve status = %d, body %s", w.Code, w.Body)
+	}
+	var d store.Draft
+	if err := json.Unmarshal(w.Body.Bytes(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Status != store.DraftPosted || d.ReplyID != "900" {
+		t.Fatalf("approved draft = %+v", d)
+	}
+	if len(rp.posted) != 1 || rp.posted[0] != (ReplyIn{InReplyTo: "1", Text: "BTC is $1"}) {
+		t.Fatalf("replies = %+v, want the draft under the mention", rp.posted)
+	}
+	if rec, _, _ := st.Get("1"); rec.Status != store.StatusPosted || rec.ReplyID != "900" || rec.ConversationID != "c" {
+		t.Fatalf("record = %+v, want posted", rec)
+	}
+	e, err := st.GetAudit("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := e.Last(); len(e.Attempts) != 2 || last.Action != AuditApprove || last.Outcome != "posted" || last.ReplyID != "900" {
+		t.Fatalf("audit attempts = %+v, want the drafted run then the approval", e.Attempts)
+	}
+	if e.Mention.Text != m.Text {
+		t.Errorf("audit mention = %+v, want the original mention kept", e.Mention)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/drafts/1/approve", nil))
+	if w.Code != http.StatusConflict || len(rp.posted) != 1 {
+		t.Fatalf("second approve = %d with %d replies, want a conflict and no new reply", w.Code, len(rp.posted))
+	}
+}
+
+func TestRejectIsAudited(t *testing.T) {
+	st := openStore(t)
+	if err := st.PutDraft(store.Draft{TweetID: "1", AuthorUsername: "alice", Question: "price of btc?", Text: "BTC is $1"}); err != nil {
+		t.Fatal(err)
+	}
+	r := chi.NewRouter()
+	r.Post("/admin/drafts/{id}/reject", DraftsHandler{Store: st}.Reject)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/admin/drafts/1/reject", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("reject status = %d, body %s", w.Code, w.Body)
+	}
+	if rec, _, _ := st.Get("1"); rec.Status != store.StatusSkipped {
+		t.Fatalf("record status = %q, want skipped", rec.Status)
+	}
+	e, err := st.GetAudit("1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := e.Last(); last.Action != AuditReject || last.Outcome != "skipped" || last.Reason != SkipRejected || e.AuthorUsername != "alice" {
+		t.Fatalf("audit = %+v, want a reject attempt", e)
+	}
+}
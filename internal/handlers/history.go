@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"cg-mentions-bot/internal/store"
+	"cg-mentions-bot/internal/types"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Audit actions recorded in store.AuditAttempt.
+const (
+	AuditProcess = "process"
+	AuditRepost  = "repost"
+	AuditApprove = "approve"
+	AuditReject  = "reject"
+)
+
+// SkipRejected is the audit reason for drafts an operator rejected.
+const SkipRejected = "rejected"
+
+var errNoAnswer = errors.New("mention has no answer to re-post")
+
+// audit appends the finished run of m to the audit log.
+func (h MentionsHandler) audit(m types.Mention, res types.MentionResult, outcome, reason string, took time.Duration) {
+	if h.Store == nil {
+		return
+	}
+	err := h.Store.AppendAudit(m, normalizeTweetText(m.Text), store.AuditAttempt{
+		Action:     AuditProcess,
+		Outcome:    outcome,
+		Reason:     reason,
+		Category:   res.Category,
+		Lang:       res.Lang,
+		Question:   res.Question,
+		Answer:     res.Answer,
+		Tools:      res.Tools,
+		Posted:     res.Posted,
+		ReplyID:    res.ReplyID,
+		Error:      res.Error,
+		TraceID:    res.TraceID,
+		DurationMS: took.Milliseconds(),
+	})
+	if err != nil {
+		log.Printf("store: audit %s: %v", m.TweetID, err)
+	}
+}
+
+// HistoryHandler lets operators browse answered mentions and replay them.
+type HistoryHandler struct {
+	Store    *store.Store
+	Mentions MentionsHandler
+}
+
+// List handles GET /admin/mentions with optional filters: status (posted,
+// drafted, skipped, failed), author, category, q (text search), since (RFC
+// 3339 time or a duration such as 24h) and limit (default 50).
+func (h HistoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := store.AuditFilter{
+		Outcome:  q.Get("status"),
+		Author:   q.Get("author"),
+		Category: q.Get("category"),
+		Text:     q.Get("q"),
+		Limit:    50,
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "bad request: limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		f.Limit = n
+	}
+	if v := q.Get("since"); v != "" {
+		since, err := parseSince(v, time.Now())
+		if err != nil {
+			http.Error(w, "bad request: since must be an RFC 3339 time or a duration", http.StatusBadRequest)
+			return
+		}
+		f.Since = since
+	}
+	entries, err := h.Store.ListAudit(f)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(entries), "mentions": entries})
+}
+
+// Get handles GET /admin/mentions/{tweet_id}: the mention with every attempt,
+// plus its current record (status and answer) when there is one.
+func (h HistoryHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "tweet_id")
+	e, err := h.Store.GetAudit(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	out := struct {
+		store.AuditEntry
+		Record *store.Record `json:"record,omitempty"`
+	}{AuditEntry: e}
+	if rec, found, err := h.Store.Get(id); err == nil && found {
+		out.Record = &rec
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// Rerun handles POST /admin/mentions/{tweet_id}/rerun: the mention goes
+// through the pipeline again even if it was answered, and may be replied to a
+// second time. With ?dry_run=1 the new answer is only drafted and returned.
+// Reruns are processed inline and return the result.
+func (h HistoryHandler) Rerun(w http.ResponseWriter, r *http.Request) {
+	e, err := h.Store.GetAudit(chi.URLParam(r, "tweet_id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	dryRun := isDryRun(r)
+	if !dryRun {
+		// Drop the processed record so the claim succeeds again.
+		if err := h.Store.Release(e.TweetID); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, h.Mentions.process(r.Context(), e.Mention, dryRun))
+}
+
+// Repost handles POST /admin/mentions/{tweet_id}/repost: it posts the latest
+// answer again (or {"text":"..."} when given) without re-running the agent,
+// e.g. after the reply was deleted or posting failed.
+func (h HistoryHandler) Repost(w http.ResponseWriter, r *http.Request) {
+	e, err := h.Store.GetAudit(chi.URLParam(r, "tweet_id"))
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var in struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil && err != io.EOF {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	text := strings.TrimSpace(in.Text)
+	if text == "" {
+		text = lastAnswer(e)
+	}
+	if text == "" {
+		http.Error(w, errNoAnswer.Error(), http.StatusConflict)
+		return
+	}
+	if h.Mentions.Reply == nil {
+		http.Error(w, errNoPoster.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	start := time.Now()
+	res := h.Mentions.post(r.Context(), e.TweetID, text)
+	outcome, reason := outcomeOf(res)
+	if err := h.Store.AppendAudit(e.Mention, e.NormalizedText, store.AuditAttempt{
+		Action:     AuditRepost,
+		Outcome:    outcome,
+		Reason:     reason,
+		Answer:     text,
+		Posted:     res.Posted,
+		ReplyID:    res.ReplyID,
+		Error:      res.Error,
+		DurationMS: time.Since(start).Milliseconds(),
+	}); err != nil {
+		log.Printf("store: audit %s: %v", e.TweetID, err)
+	}
+	if res.Posted {
+		rec := store.Record{
+			TweetID:        e.TweetID,
+			ConversationID: e.Mention.ConversationID,
+			AuthorUsername: e.AuthorUsername,
+			Question:       e.NormalizedText,
+			Answer:         text,
+			ReplyID:        res.ReplyID,
+			Status:         store.StatusPosted,
+		}
+		if err := h.Store.Finish(rec); err != nil {
+			log.Printf("store: record %s: %v", e.TweetID, err)
+		}
+		h.Mentions.deadLetter(e.Mention, rec)
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// lastAnswer returns the most recent non-empty answer in e.
+func lastAnswer(e store.AuditEntry) string {
+	for i := len(e.Attempts) - 1; i >= 0; i-- {
+		if a := e.Attempts[i].Answer; a != "" {
+			return a
+		}
+	}
+	return ""
+}
+
+// parseSince accepts an RFC 3339 time or a duration counted back from now.
+func parseSince(v string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"cg-mentions-bot/internal/jobs"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// JobsAuth guards /jobs, whose results include drafts: it accepts the admin
+// token, or the same X-Webhook-Secret and HMAC signature (over the empty body)
+// that /mentions requires. Without any of these configured, /jobs is as open
+// as /mentions.
+func (h MentionsHandler) JobsAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if h.AdminToken != "" && subtle.ConstantTimeCompare([]byte(bearer), []byte(h.AdminToken)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		webhookAuth := h.Secret != "" || h.Verifier != nil
+		if !webhookAuth && h.AdminToken != "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if h.Secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Webhook-Secret")), []byte(h.Secret)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if h.Verifier != nil {
+			if err := h.Verifier.Verify(r.Header, nil); err != nil {
+				http.Error(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// JobsHandler exposes the status of queued mention jobs.
+type JobsHandler struct {
+	Queue *jobs.Queue
+}
+
+// Get handles GET /jobs/{id}.
+func (h JobsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	j, ok := h.Queue.Get(chi.URLParam(r, "id"))
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, j)
+}
+
+// List handles GET /jobs?batch=<batch_id>. Without a batch it lists all retained jobs.
+func (h JobsHandler) List(w http.ResponseWriter, r *http.Request) {
+	list := h.Queue.List(r.URL.Query().Get("batch"))
+	writeJSON(w, http.StatusOK, struct {
+		Count int        `json:"count"`
+		Jobs  []jobs.Job `json:"jobs"`
+	}{Count: len(list), Jobs: list})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -1,23 +1,87 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"io"
+	"log"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"cg-mentions-bot/internal/agent"
+	"cg-mentions-bot/internal/classify"
+	"cg-mentions-bot/internal/entities"
+	"cg-mentions-bot/internal/i18n"
+	"cg-mentions-bot/internal/jobs"
+	"cg-mentions-bot/internal/metrics"
+	"cg-mentions-bot/internal/policy"
+	"cg-mentions-bot/internal/store"
+	"cg-mentions-bot/internal/tracing"
 	"cg-mentions-bot/internal/types"
+	"cg-mentions-bot/internal/webhook"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // MentionsHandler handles POST /mentions events.
 type MentionsHandler struct {
+	// Secret is the legacy shared secret compared against X-Webhook-Secret.
 	Secret string
-	Ask    func(ctx context.Context, text string) (string, error)
-	Reply  func(ctx context.Context, in ReplyIn) error
+	// If set, requests must carry a valid HMAC signature (see webhook.Verifier).
+	Verifier *webhook.Verifier
+	// AdminToken is also accepted as "Authorization: Bearer" on /jobs (see JobsAuth).
+	AdminToken string
+	Ask        func(ctx context.Context, text string) (string, error)
+	// Reply posts a reply and returns its tweet ID ("" when the poster cannot tell).
+	Reply func(ctx context.Context, in ReplyIn) (string, error)
 	// If set, uses the agent binary to both answer and post per mention.
-	AgentRun func(ctx context.Context, question string, replyTo string) (string, error)
+	AgentRun func(ctx context.Context, req agent.Request) (agent.Result, error)
+	// If set, mentions are processed asynchronously and /mentions returns job IDs.
+	Queue *jobs.Queue
+	// If set, remembers answered tweets so overlapping batches are not replied to twice.
+	Store *store.Store
+	// If set, tags each mention and applies the per-category policy before answering.
+	Classifier *classify.Classifier
+	// If set, enforces per-author quotas, block/allow lists and ignores our own tweets.
+	Authors *policy.Authors
+	// If set, returns the earlier tweets above m (oldest first) so follow-up
+	// questions can be answered in context; when a lookup fails it returns the
+	// nearer tweets it found with the error. Without it, context comes from the Store.
+	Thread func(ctx context.Context, m types.Mention) ([]Turn, error)
+	// If set, resolves cashtags and coin names to CoinGecko IDs and hints them to the agent.
+	Entities *entities.Catalog
+	// If set, decides how mentions that waited in a batch are answered (or skipped).
+	Staleness *policy.Staleness
+	// Settings holds the posting mode, prompt instructions and agent tool
+	// allowlist; they may change between mentions.
+	Settings *LiveSettings
+}
+
+// SkipAlreadyProcessed is reported for mentions the Store has already answered.
+const SkipAlreadyProcessed = "already_processed"
+
+// Failure stages reported in MentionResult.Failure.
+const (
+	FailureStore = "store"
+	FailureAgent = "agent"
+	FailureAsk   = "ask"
+	FailureReply = "reply"
+)
+
+// isDryRun reports whether the request asks for drafts only (?dry_run=1 or X-Dry-Run: 1).
+func isDryRun(r *http.Request) bool {
+	v := r.URL.Query().Get("dry_run")
+	if v == "" {
+		v = r.Header.Get("X-Dry-Run")
+	}
+	on, _ := strconv.ParseBool(v)
+	return on
 }
 
 // ReplyIn contains minimal info to reply to a tweet.
@@ -26,9 +90,21 @@ type ReplyIn struct {
 	Text      string
 }
 
-// Handle verifies secret (if configured), processes mentions, and returns a summary.
+// post sends in through reply inside a "post_reply" span and returns the
+// reply's tweet ID.
+func post(ctx context.Context, reply func(context.Context, ReplyIn) (string, error), in ReplyIn) (string, error) {
+	ctx, span := tracing.Start(ctx, "post_reply", attribute.String("tweet.in_reply_to", in.InReplyTo))
+	id, err := reply(ctx, in)
+	span.SetAttributes(attribute.String("tweet.reply_id", id))
+	tracing.End(span, err)
+	return id, err
+}
+
+// Handle verifies the signature or secret (if configured), processes mentions, and returns a summary.
+// When a Queue is configured, mentions are enqueued and the summary lists job IDs instead.
+// Dry-run requests are always answered inline and return drafts without posting.
 func (h MentionsHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	if h.Secret != "" && r.Header.Get("X-Webhook-Secret") != h.Secret {
+	if h.Secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Webhook-Secret")), []byte(h.Secret)) != 1 {
 		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
@@ -39,16 +115,17 @@ func (h MentionsHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Accept either a single payload object or an array of payloads
-	var payload types.MentionsPayload
-	var payloads []types.MentionsPayload
-	if err := json.Unmarshal(body, &payloads); err != nil {
-		// Not an array, try single
-		if err2 := json.Unmarshal(body, &payload); err2 != nil {
-			http.Error(w, "bad request", http.StatusBadRequest)
+	if h.Verifier != nil {
+		if err := h.Verifier.Verify(r.Header, body); err != nil {
+			http.Error(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
 			return
 		}
-		payloads = []types.MentionsPayload{payload}
+	}
+
+	payloads, err := parsePayloads(body)
+	if err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
 	}
 
 	// Flatten mentions
@@ -66,52 +143,343 @@ func (h MentionsHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		received = len(mentions)
 	}
 
-	type res struct {
-		TweetID string `json:"tweet_id"`
-		Posted  bool   `json:"posted"`
-		Error   string `json:"error,omitempty"`
+	metrics.MentionsReceived.WithLabelValues("webhook").Add(float64(len(mentions)))
+	dryRun := isDryRun(r)
+	if h.Queue != nil && !dryRun {
+		h.enqueue(r.Context(), w, received, mentions)
+		return
 	}
 
-	results := make([]res, 0, len(mentions))
-
+	results := make([]types.MentionResult, 0, len(mentions))
 	for _, m := range mentions {
-		q := normalizeTweetText(m.Text)
-		if h.AgentRun != nil {
-			if _, err := h.AgentRun(r.Context(), q, m.TweetID); err != nil {
-				results = append(results, res{TweetID: m.TweetID, Posted: false, Error: err.Error()})
-			} else {
-				results = append(results, res{TweetID: m.TweetID, Posted: true})
+		results = append(results, h.process(r.Context(), m, dryRun))
+	}
+
+	summary := struct {
+		Received  int                   `json:"received"`
+		Processed int                   `json:"processed"`
+		Results   []types.MentionResult `json:"results"`
+	}{
+		Received:  received,
+		Processed: len(mentions),
+		Results:   results,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(summary)
+}
+
+// Dispatch feeds mentions from other sources, such as the poller, through the
+// same path as POST /mentions. It returns how many were accepted; with a Queue,
+// an error means some mentions could not be enqueued.
+func (h MentionsHandler) Dispatch(ctx context.Context, mentions []types.Mention) (int, error) {
+	if h.Queue == nil {
+		for _, m := range mentions {
+			if res := h.Process(ctx, m); res.Error != "" {
+				log.Printf("mention %s: %s", m.TweetID, res.Error)
 			}
+		}
+		return len(mentions), nil
+	}
+
+	batchID := jobs.NewBatchID()
+	for i, m := range mentions {
+		if h.skipEarly(m) != "" {
 			continue
 		}
+		if _, err := h.Queue.Enqueue(ctx, batchID, m); err != nil {
+			return i, err
+		}
+	}
+	return len(mentions), nil
+}
 
-		ans, err := h.Ask(r.Context(), q)
+// skipEarly returns why m can be skipped before it is queued, or "" when it
+// has to go through the pipeline. Mentions the Store has already answered,
+// and authors the lists or our own account rule out, never take up a worker;
+// the quota is left to run, which knows whether the mention was answered.
+func (h MentionsHandler) skipEarly(m types.Mention) string {
+	reason := ""
+	if h.Store != nil {
+		rec, found, err := h.Store.Get(m.TweetID)
 		if err != nil {
-			results = append(results, res{TweetID: m.TweetID, Posted: false, Error: err.Error()})
-			continue
+			// Let the pipeline's Claim report the store failure.
+			log.Printf("store: get %s: %v", m.TweetID, err)
+		}
+		if found && (rec.Status == store.StatusPosted || rec.Status == store.StatusSkipped || rec.Status == store.StatusDrafted) {
+			reason = SkipAlreadyProcessed
 		}
+	}
+	if reason == "" && h.Authors != nil {
+		reason = h.Authors.Check(m)
+	}
+	if reason != "" {
+		metrics.MentionsProcessed.WithLabelValues("skipped", reason).Inc()
+	}
+	return reason
+}
 
-		if postErr := h.Reply(r.Context(), ReplyIn{InReplyTo: m.TweetID, Text: ans}); postErr != nil {
-			results = append(results, res{TweetID: m.TweetID, Posted: false, Error: postErr.Error()})
-			continue
+// Process answers and replies to a single mention. Mentions already recorded in
+// the Store, or rejected by the Authors policy, are skipped.
+func (h MentionsHandler) Process(ctx context.Context, m types.Mention) types.MentionResult {
+	return h.process(ctx, m, false)
+}
+
+// outcomeOf names the result for metrics and traces, with the skip reason or failed stage.
+func outcomeOf(res types.MentionResult) (outcome, reason string) {
+	switch {
+	case res.DryRun:
+		return "dry_run", res.Skipped
+	case res.Skipped != "":
+		return "skipped", res.Skipped
+	case res.PendingApproval:
+		return "drafted", ""
+	case res.Posted:
+		return "posted", ""
+	}
+	return "failed", res.Failure
+}
+
+// process runs the pipeline for m in its own span and records the outcome in
+// the metrics. The result carries the trace ID for correlation.
+func (h MentionsHandler) process(ctx context.Context, m types.Mention, dryRun bool) types.MentionResult {
+	ctx, span := tracing.Start(ctx, "mention",
+		attribute.String("tweet.id", m.TweetID),
+		attribute.String("tweet.author", m.AuthorUsername),
+		attribute.Bool("dry_run", dryRun))
+	start := time.Now()
+	res := h.run(ctx, m, dryRun)
+	res.TraceID = tracing.TraceID(ctx)
+
+	outcome, reason := outcomeOf(res)
+	metrics.MentionsProcessed.WithLabelValues(outcome, reason).Inc()
+	if !dryRun && res.Skipped != SkipAlreadyProcessed {
+		h.audit(m, res, outcome, reason, time.Since(start))
+	}
+	span.SetAttributes(attribute.String("mention.outcome", outcome), attribute.String("mention.reason", reason))
+	var err error
+	if res.Error != "" {
+		err = errors.New(res.Error)
+	}
+	tracing.End(span, err)
+	return res
+}
+
+// run is the pipeline itself. A dry run drafts the reply without posting it
+// and leaves no trace in the Store or the author quotas.
+func (h MentionsHandler) run(ctx context.Context, m types.Mention, dryRun bool) types.MentionResult {
+	if dryRun {
+		if h.Authors != nil {
+			hit, reason := h.Authors.Admit(m)
+			if reason != "" {
+				return types.MentionResult{TweetID: m.TweetID, DryRun: true, Skipped: reason}
+			}
+			h.Authors.Release(hit)
 		}
+		ans, res := h.respond(ctx, m, true)
+		res.DryRun = true
+		res.Answer = ans
+		return res
+	}
+
+	if h.Store != nil {
+		claimed, err := h.Store.Claim(m.TweetID)
+		if err != nil {
+			return types.MentionResult{TweetID: m.TweetID, Error: err.Error(), Failure: FailureStore}
+		}
+		if !claimed {
+			return types.MentionResult{TweetID: m.TweetID, Skipped: SkipAlreadyProcessed}
+		}
+	}
+
+	var hit policy.Hit
+	if h.Authors != nil {
+		var reason string
+		if hit, reason = h.Authors.Admit(m); reason != "" {
+			// Leave no record so the mention is reconsidered if the policy changes.
+			if h.Store != nil {
+				if err := h.Store.Release(m.TweetID); err != nil {
+					log.Printf("store: release %s: %v", m.TweetID, err)
+				}
+			}
+			return types.MentionResult{TweetID: m.TweetID, Skipped: reason}
+		}
+	}
 
-		results = append(results, res{TweetID: m.TweetID, Posted: true})
+	approval := h.Settings.Load().RequireApproval
+	ans, res := h.respond(ctx, m, approval)
+	res.Answer = ans
+	if approval && res.Draft != "" {
+		res = h.saveDraft(m, res)
+	}
+	if h.Authors != nil && !res.Posted && !res.PendingApproval {
+		h.Authors.Release(hit)
+	}
+
+	if h.Store != nil {
+		rec := store.Record{
+			TweetID:        m.TweetID,
+			ConversationID: m.ConversationID,
+			AuthorUsername: m.AuthorUsername,
+			Question:       normalizeTweetText(m.Text),
+			Answer:         ans,
+			ReplyID:        res.ReplyID,
+			Status:         store.StatusPosted,
+		}
+		switch {
+		case res.Skipped != "":
+			rec.Status = store.StatusSkipped
+		case res.PendingApproval:
+			rec.Status = store.StatusDrafted
+		case !res.Posted:
+			rec.Status = store.StatusFailed
+			rec.Error = res.Error
+		}
+		if err := h.Store.Finish(rec); err != nil {
+			log.Printf("store: record %s: %v", m.TweetID, err)
+		}
+		h.deadLetter(m, rec)
+	}
+	return res
+}
+
+// answer runs the agent, or the legacy ask + reply flow, for one mention. With
+// draftOnly it stops before anything is posted and returns the draft instead.
+func (h MentionsHandler) answer(ctx context.Context, m types.Mention, stale policy.Verdict, draftOnly bool) (string, types.MentionResult) {
+	lang := language(m)
+	ents := h.entities(m)
+	coins := coinIDs(ents)
+	settings := h.Settings.Load()
+	q := h.question(ctx, m, lang, ents, stale, settings.Instructions)
+	if h.AgentRun != nil {
+		start := time.Now()
+		// When the bot posts, the agent only answers and never sees x_post_reply.
+		botPosts := settings.BotPosts && !draftOnly
+		actx, span := tracing.Start(ctx, "agent.run", attribute.Bool("agent.dry_run", draftOnly), attribute.Bool("agent.bot_posts", botPosts))
+		out, err := h.AgentRun(actx, agent.Request{Question: q, ReplyTo: m.TweetID, DryRun: draftOnly || botPosts, Tools: settings.AgentTools})
+		span.SetAttributes(
+			attribute.StringSlice("agent.tools", out.Tools),
+			attribute.Int("agent.iterations", out.Iterations),
+			attribute.Int("llm.total_tokens", out.Usage.TotalTokens),
+		)
+		tracing.End(span, err)
+		metrics.AgentRunSeconds.WithLabelValues(metrics.Outcome(err)).Observe(time.Since(start).Seconds())
+		for _, t := range out.Tools {
+			metrics.AgentToolCalls.WithLabelValues(t).Inc()
+		}
+		res := types.MentionResult{TweetID: m.TweetID, Lang: lang, Coins: coins, Stale: string(stale.Mode), Tools: out.Tools, Question: q}
+		switch {
+		case out.Posted:
+			// The reply is out even if the agent failed afterwards.
+			res.Posted = true
+			res.ReplyID = out.PostedTweetID
+		case err != nil:
+			res.Error = err.Error()
+			res.Failure = FailureAgent
+			var aerr *agent.Error
+			if errors.As(err, &aerr) && (aerr.Kind == agent.ErrPost || aerr.Kind == agent.ErrNotPosted) {
+				res.Failure = FailureReply
+			}
+		case draftOnly:
+			res.Draft = out.Answer
+			res.Steps = out.Steps
+		case botPosts && strings.TrimSpace(out.Answer) == "":
+			res.Error = "agent returned an empty answer"
+			res.Failure = FailureAgent
+		case botPosts:
+			// Pinned to the mention: the model cannot pick another tweet or skip the reply.
+			posted := h.post(ctx, m.TweetID, out.Answer)
+			res.Posted, res.ReplyID, res.Error, res.Failure = posted.Posted, posted.ReplyID, posted.Error, posted.Failure
+		default:
+			res.Error = "agent finished without posting a reply"
+			res.Failure = FailureReply
+		}
+		return out.Answer, res
+	}
+
+	actx, span := tracing.Start(ctx, "cg.ask")
+	ans, err := h.Ask(actx, q)
+	tracing.End(span, err)
+	if err != nil {
+		return "", types.MentionResult{TweetID: m.TweetID, Lang: lang, Coins: coins, Posted: false, Error: err.Error(), Failure: FailureAsk, Question: q}
+	}
+	if loc, ok := i18n.LocaleFor(lang); ok {
+		ans = loc.Localize(ans)
+	}
+	// The MCP tool only returns current data, so stale answers are always stamped.
+	if stale.Mode != "" {
+		ans = stampAnswer(ans, time.Now())
+	}
+	if draftOnly {
+		return ans, types.MentionResult{TweetID: m.TweetID, Lang: lang, Coins: coins, Stale: string(stale.Mode), Draft: ans, Question: q}
+	}
+
+	replyID, postErr := post(ctx, h.Reply, ReplyIn{InReplyTo: m.TweetID, Text: ans})
+	if postErr != nil {
+		return ans, types.MentionResult{TweetID: m.TweetID, Lang: lang, Coins: coins, Stale: string(stale.Mode), Posted: false, Error: postErr.Error(), Failure: FailureReply, Question: q}
+	}
+
+	return ans, types.MentionResult{TweetID: m.TweetID, Lang: lang, Coins: coins, Stale: string(stale.Mode), Posted: true, ReplyID: replyID, Question: q}
+}
+
+// enqueue schedules each mention on the worker pool and responds with the job IDs.
+func (h MentionsHandler) enqueue(ctx context.Context, w http.ResponseWriter, received int, mentions []types.Mention) {
+	type queued struct {
+		ID      string      `json:"id,omitempty"`
+		TweetID string      `json:"tweet_id"`
+		Status  jobs.Status `json:"status,omitempty"`
+		Skipped string      `json:"skipped,omitempty"`
+		Error   string      `json:"error,omitempty"`
+	}
+
+	batchID := jobs.NewBatchID()
+	queuedJobs := make([]queued, 0, len(mentions))
+	n := 0
+	for _, m := range mentions {
+		if reason := h.skipEarly(m); reason != "" {
+			queuedJobs = append(queuedJobs, queued{TweetID: m.TweetID, Skipped: reason})
+			continue
+		}
+		j, err := h.Queue.Enqueue(ctx, batchID, m)
+		if err != nil {
+			queuedJobs = append(queuedJobs, queued{TweetID: m.TweetID, Error: err.Error()})
+			continue
+		}
+		n++
+		queuedJobs = append(queuedJobs, queued{ID: j.ID, TweetID: j.TweetID, Status: j.Status})
 	}
 
 	summary := struct {
-		Received  int   `json:"received"`
-		Processed int   `json:"processed"`
-		Results   []res `json:"results"`
+		Received int      `json:"received"`
+		Queued   int      `json:"queued"`
+		BatchID  string   `json:"batch_id"`
+		Jobs     []queued `json:"jobs"`
 	}{
-		Received:  received,
-		Processed: len(mentions),
-		Results:   results,
+		Received: received,
+		Queued:   n,
+		BatchID:  batchID,
+		Jobs:     queuedJobs,
 	}
+	writeJSON(w, http.StatusAccepted, summary)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusAccepted)
-	_ = json.NewEncoder(w).Encode(summary)
+// parsePayloads accepts either a single payload object or an array of payloads.
+// Invalid mentions, such as a malformed created_at, reject the whole body.
+func parsePayloads(body []byte) ([]types.MentionsPayload, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var payloads []types.MentionsPayload
+		if err := json.Unmarshal(trimmed, &payloads); err != nil {
+			return nil, err
+		}
+		return payloads, nil
+	}
+	var payload types.MentionsPayload
+	if err := json.Unmarshal(trimmed, &payload); err != nil {
+		return nil, err
+	}
+	return []types.MentionsPayload{payload}, nil
 }
 
 // normalizeTweetText removes handles and URLs and trims whitespace to form a concise question input.
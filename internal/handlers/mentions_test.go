@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cg-mentions-bot/internal/agent"
+	"cg-mentions-bot/internal/jobs"
+	"cg-mentions-bot/internal/policy"
+	"cg-mentions-bot/internal/store"
+	"cg-mentions-bot/internal/tracing"
+	"cg-mentions-bot/internal/types"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestQueuedMentionJoinsRequestTrace(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	h := MentionsHandler{
+		Ask:   func(context.Context, string) (string, error) { return "BTC is $1", nil },
+		Reply: func(context.Context, ReplyIn) (string, error) { return "2", nil },
+	}
+	h.Queue = jobs.NewQueue(1, 10, h.Process)
+	h.Queue.Start(context.Background())
+
+	req := httptest.NewRequest(http.MethodPost, "/mentions", strings.NewReader(`{"mentions":[{"tweet_id":"1","text":"price of btc?"}]}`))
+	w := httptest.NewRecorder()
+	tracing.Handler(http.HandlerFunc(h.Handle)).ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	h.Queue.Shutdown(context.Background())
+
+	var webhook, mention sdktrace.ReadOnlySpan
+	for _, s := range rec.Ended() {
+		switch s.Name() {
+		case "POST /mentions":
+			webhook = s
+		case "mention":
+			mention = s
+		}
+	}
+	if webhook == nil || mention == nil {
+		t.Fatalf("missing spans: webhook %v, mention %v", webhook != nil, mention != nil)
+	}
+	if got, want := mention.SpanContext().TraceID(), webhook.SpanContext().TraceID(); got != want {
+		t.Errorf("mention trace = %s, want %s", got, want)
+	}
+	if got, want := mention.Parent().SpanID(), webhook.SpanContext().SpanID(); got != want {
+		t.Errorf("mention parent = %s, want %s", got, want)
+	}
+}
+
+func openStore(t *testing.T) *store.Store {
+	t.Helper()
+	s, err := store.Open(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// queuedJob is one entry of the /mentions summary when a Queue is configured.
+type queuedJob struct {
+	ID      string `json:"id"`
+	TweetID string `json:"tweet_id"`
+	Skipped string `json:"skipped"`
+}
+
+// postMentions sends body to h.Handle and decodes the queued summary.
+func postMentions(t *testing.T, h MentionsHandler, body string) []queuedJob {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.Handle(w, httptest.NewRequest(http.MethodPost, "/mentions", strings.NewReader(body)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var summary struct {
+		Jobs []queuedJob `json:"jobs"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	return summary.Jobs
+}
+
+func TestAnsweredMentionsAreNotQueued(t *testing.T) {
+	st := openStore(t)
+	for id, status := range map[string]store.Status{"1": store.StatusPosted, "2": store.StatusFailed} {
+		if err := st.Finish(store.Record{TweetID: id, Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := MentionsHandler{Store: st, Queue: jobs.NewQueue(1, 10, func(context.Context, types.Mention) types.MentionResult {
+		return types.MentionResult{}
+	})}
+
+	got := postMentions(t, h, `{"mentions":[{"tweet_id":"1","text":"btc?"},{"tweet_id":"2","text":"eth?"},{"tweet_id":"3","text":"sol?"}]}`)
+	if len(got) != 3 {
+		t.Fatalf("jobs = %+v, want 3 entries", got)
+	}
+	if got[0].Skipped != SkipAlreadyProcessed || got[0].ID != "" {
+		t.Errorf("answered mention = %+v, want skipped as %s", got[0], SkipAlreadyProcessed)
+	}
+	for _, j := range got[1:] {
+		if j.Skipped != "" || j.ID == "" {
+			t.Errorf("mention %s = %+v, want queued", j.TweetID, j)
+		}
+	}
+	if n := len(h.Queue.List("")); n != 2 {
+		t.Errorf("queued %d jobs, want 2", n)
+	}
+}
+
+func TestRejectedAuthorsAreNotQueued(t *testing.T) {
+	authors := policy.NewAuthors(nil, []string{"spammer"})
+	authors.SelfUsername = "bot"
+	authors.Limit, authors.Window = 1, time.Hour
+	h := MentionsHandler{Authors: authors, Queue: jobs.NewQueue(1, 10, func(context.Context, types.Mention) types.MentionResult {
+		return types.MentionResult{}
+	})}
+
+	got := postMentions(t, h, `{"mentions":[
+		{"tweet_id":"1","text":"btc?","author_username":"spammer"},
+		{"tweet_id":"2","text":"btc?","author_username":"bot"},
+		{"tweet_id":"3","text":"btc?","author_username":"alice"},
+		{"tweet_id":"4","text":"eth?","author_username":"alice"}]}`)
+	want := []string{policy.ReasonBlocked, policy.ReasonSelf, "", ""}
+	if len(got) != len(want) {
+		t.Fatalf("jobs = %+v, want %d entries", got, len(want))
+	}
+	for i, j := range got {
+		if j.Skipped != want[i] || (j.ID == "") != (want[i] != "") {
+			t.Errorf("mention %s = %+v, want skipped %q", j.TweetID, j, want[i])
+		}
+	}
+	// The quota is only spent when a job runs, so both of alice's mentions are queued.
+	if n := len(h.Queue.List("")); n != 2 {
+		t.Errorf("queued %d jobs, want 2", n)
+	}
+}
+
+// fakeAgent records the requests it gets and answers each with answer.
+type fakeAgent struct {
+	answer string
+	reqs   []agent.Request
+}
+
+func (f *fakeAgent) run(_ context.Context, req agent.Request) (agent.Result, error) {
+	f.reqs = append(f.reqs, req)
+	return agent.Result{Answer: f.answer, Tools: []string{"get_price"}}, nil
+}
+
+// fakeReply records the replies it posts.
+type fakeReply struct {
+	posted []ReplyIn
+}
+
+func (f *fakeReply) reply(_ context.Context, in ReplyIn) (string, error) {
+	f.posted = append(f.posted, in)
+	return "900", nil
+}
+
+func TestBotPostsTheAgentAnswer(t *testing.T) {
+	ag := &fakeAgent{answer: "BTC is $1"}
+	rp := &fakeReply{}
+	st := openStore(t)
+	h := MentionsHandler{
+		AgentRun: ag.run,
+		Reply:    rp.reply,
+		Store:    st,
+		Settings: NewLiveSettings(Settings{BotPosts: true}),
+	}
+	m := types.Mention{TweetID: "1", Text: "@bot price of btc?", AuthorUsername: "alice"}
+
+	res := h.Process(context.Background(), m)
+	if !res.Posted || res.ReplyID != "900" || res.Error != "" {
+		t.Fatalf("result = %+v, want posted as 900", res)
+	}
+	if len(ag.reqs) != 1 || !ag.reqs[0].DryRun {
+		t.Fatalf("agent requests = %+v, want one without x_post_reply", ag.reqs)
+	}
+	if len(rp.posted) != 1 || rp.posted[0] != (ReplyIn{InReplyTo: "1", Text: "BTC is $1"}) {
+		t.Fatalf("replies = %+v, want the answer under the mention", rp.posted)
+	}
+	if rec, found, err := st.Get("1"); err != nil || !found || rec.Status != store.StatusPosted || rec.ReplyID != "900" {
+		t.Fatalf("record = %+v, %v, %v", rec, found, err)
+	}
+
+	if res := h.Process(context.Background(), m); res.Skipped != SkipAlreadyProcessed {
+		t.Fatalf("second result = %+v, want skipped", res)
+	}
+	if len(rp.posted) != 1 {
+		t.Fatalf("replied %d times, want once", len(rp.posted))
+	}
+}
+
+func TestDryRunNeverReplies(t *testing.T) {
+	ag := &fakeAgent{answer: "BTC is $1"}
+	rp := &fakeReply{}
+	st := openStore(t)
+	h := MentionsHandler{
+		AgentRun: ag.run,
+		Reply:    rp.reply,
+		Store:    st,
+		Settings: NewLiveSettings(Settings{BotPosts: true}),
+	}
+
+	w := httptest.NewRecorder()
+	h.Handle(w, httptest.NewRequest(http.MethodPost, "/mentions?dry_run=1", strings.NewReader(`{"mentions":[{"tweet_id":"1","text":"price of btc?"}]}`)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	var summary struct {
+		Results []types.MentionResult `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Results) != 1 || !summary.Results[0].DryRun || summary.Results[0].Draft != "BTC is $1" || summary.Results[0].Posted {
+		t.Fatalf("results = %+v, want one unposted draft", summary.Results)
+	}
+	if len(ag.reqs) != 1 || !ag.reqs[0].DryRun {
+		t.Fatalf("agent requests = %+v, want a dry run", ag.reqs)
+	}
+	if len(rp.posted) != 0 {
+		t.Fatalf("dry run replied: %+v", rp.posted)
+	}
+	if _, found, _ := st.Get("1"); found {
+		t.Fatal("dry run left a record in the store")
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cg-mentions-bot/internal/entities"
+	"cg-mentions-bot/internal/i18n"
+	"cg-mentions-bot/internal/policy"
+	"cg-mentions-bot/internal/types"
+)
+
+// maxHistory bounds how many earlier turns are passed to the agent.
+const maxHistory = 6
+
+// Turn is one earlier tweet in the conversation a mention replies into.
+type Turn struct {
+	AuthorID       string
+	AuthorUsername string
+	Text           string
+	// FromBot marks our own earlier replies.
+	FromBot bool
+}
+
+// question builds the text handed to the agent (or Ask) for m: the normalized
+// tweet with the tweet it quotes, prefixed with the earlier conversation when m
+// is a reply in a thread, and followed by the resolved coin IDs, the operator's
+// instructions, a staleness instruction for delayed mentions and a
+// reply-language instruction for non-English mentions.
+func (h MentionsHandler) question(ctx context.Context, m types.Mention, lang string, ents []entities.Entity, stale policy.Verdict, instructions string) string {
+	q := normalizeTweetText(m.Text)
+	if quoted, ok := m.Quoted(); ok && strings.TrimSpace(quoted.Text) != "" {
+		q = withQuote(q, quoted)
+	}
+	if history := h.history(ctx, m); len(history) > 0 {
+		q = withHistory(q, history)
+	}
+	if hints := entities.Hints(ents); hints != "" {
+		q += "\n\n" + hints
+	}
+	if instructions = strings.TrimSpace(instructions); instructions != "" {
+		q += "\n\n" + instructions
+	}
+	if note := staleNote(stale, time.Now()); note != "" {
+		q += "\n\n" + note
+	}
+	if loc, ok := i18n.LocaleFor(lang); ok && loc.Lang != "en" {
+		q += fmt.Sprintf("\n\nWrite the final reply in %s (%s). Format numbers and prices the way %s readers expect, e.g. %s.",
+			loc.Name, loc.Lang, loc.Name, loc.Example())
+	} else if lang != "" && lang != "en" {
+		q += fmt.Sprintf("\n\nWrite the final reply in the language with code %q.", lang)
+	}
+	return q
+}
+
+// entities resolves the coins and currencies named in m and in the tweet it
+// quotes, so "what about this coin?" on a quote resolves the quoted coin.
+// Handles and URLs are stripped first so "@solana" or a link slug is not
+// mistaken for a coin.
+func (h MentionsHandler) entities(m types.Mention) []entities.Entity {
+	if h.Entities == nil {
+		return nil
+	}
+	text := normalizeTweetText(m.Text)
+	if quoted, ok := m.Quoted(); ok {
+		text += "\n" + normalizeTweetText(quoted.Text)
+	}
+	return h.Entities.Extract(text)
+}
+
+// coinIDs lists the CoinGecko IDs among ents.
+func coinIDs(ents []entities.Entity) []string {
+	var ids []string
+	for _, e := range ents {
+		if e.Kind == entities.KindCoin {
+			ids = append(ids, e.ID)
+		}
+	}
+	return ids
+}
+
+// language picks the reply language for m: X's lang tag when it is meaningful,
+// otherwise a guess from the normalized text. "" means unknown.
+func language(m types.Mention) string {
+	if lang := i18n.Normalize(m.Lang); lang != "" {
+		return lang
+	}
+	return i18n.Detect(normalizeTweetText(m.Text))
+}
+
+// stampLayout formats times in replies and prompts, always in UTC.
+const stampLayout = "Jan 2 15:04 UTC"
+
+// staleNote tells the agent how to answer a delayed mention.
+func staleNote(v policy.Verdict, now time.Time) string {
+	asked := v.AskedAt.Format(stampLayout)
+	switch v.Mode {
+	case policy.StaleStamp:
+		return fmt.Sprintf("This question was asked at %s (%s ago). Answer with current data and begin the reply with \"As of %s,\" so readers know when the figures are from.",
+			asked, policy.FormatAge(v.Age), now.UTC().Format(stampLayout))
+	case policy.StaleHistorical:
+		return fmt.Sprintf("This question was asked at %s (%s ago, unix time %d). Answer with the figures as of that time using CoinGecko historical data (e.g. market chart by time range around it), not current prices, and begin the reply with \"At %s, when you asked,\".",
+			asked, policy.FormatAge(v.Age), v.AskedAt.Unix(), asked)
+	}
+	return ""
+}
+
+// stampAnswer prefixes ans with the time its data is from.
+func stampAnswer(ans string, now time.Time) string {
+	return fmt.Sprintf("As of %s: %s", now.UTC().Format(stampLayout), ans)
+}
+
+func withQuote(q string, quoted types.ReferencedTweet) string {
+	who := "a tweet"
+	if quoted.AuthorUsername != "" {
+		who = "a tweet by @" + quoted.AuthorUsername
+	}
+	return fmt.Sprintf("The user is quoting %s: %q\nQuestion about the quoted tweet (\"this\" refers to it): %s",
+		who, normalizeTweetText(quoted.Text), q)
+}
+
+func withHistory(q string, history []Turn) string {
+	var b strings.Builder
+	b.WriteString("Earlier in this conversation:\n")
+	for _, t := range history {
+		who := "@" + t.AuthorUsername
+		if t.AuthorUsername == "" {
+			who = "user"
+		}
+		if t.FromBot {
+			who = "you (our earlier reply)"
+		}
+		fmt.Fprintf(&b, "- %s: %s\n", who, normalizeTweetText(t.Text))
+	}
+	b.WriteString("\nFollow-up question (resolve it against the conversation above): ")
+	b.WriteString(q)
+	return b.String()
+}
+
+// history returns earlier turns for replies, from X when a Thread fetcher is
+// configured and otherwise from our own answers recorded in the Store.
+func (h MentionsHandler) history(ctx context.Context, m types.Mention) []Turn {
+	if m.ConversationID == "" || m.ConversationID == m.TweetID {
+		return nil
+	}
+
+	if h.Thread != nil {
+		turns, err := h.Thread(ctx, m)
+		if err != nil {
+			log.Printf("mention %s: thread lookup: %v", m.TweetID, err)
+		}
+		if len(turns) > 0 {
+			for i := range turns {
+				turns[i].FromBot = turns[i].FromBot || h.isBot(turns[i])
+			}
+			if len(turns) > maxHistory {
+				turns = turns[len(turns)-maxHistory:]
+			}
+			return turns
+		}
+	}
+
+	if h.Store == nil {
+		return nil
+	}
+	recs, err := h.Store.Conversation(m.ConversationID, maxHistory/2)
+	if err != nil {
+		log.Printf("mention %s: conversation lookup: %v", m.TweetID, err)
+		return nil
+	}
+	var turns []Turn
+	for _, r := range recs {
+		if r.TweetID == m.TweetID {
+			continue
+		}
+		turns = append(turns,
+			Turn{AuthorUsername: r.AuthorUsername, Text: r.Question},
+			Turn{Text: r.Answer, FromBot: true},
+		)
+	}
+	return turns
+}
+
+func (h MentionsHandler) isBot(t Turn) bool {
+	if h.Authors == nil {
+		return false
+	}
+	return (h.Authors.SelfID != "" && t.AuthorID == h.Authors.SelfID) ||
+		(h.Authors.SelfUsername != "" && strings.EqualFold(t.AuthorUsername, h.Authors.SelfUsername))
+}
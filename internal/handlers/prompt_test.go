@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cg-mentions-bot/internal/policy"
+	"cg-mentions-bot/internal/types"
+)
+
+func TestHistoryKeepsPartialThread(t *testing.T) {
+	authors := policy.NewAuthors(nil, nil)
+	authors.SelfUsername = "cgbot"
+	h := MentionsHandler{
+		Authors: authors,
+		Thread: func(context.Context, types.Mention) ([]Turn, error) {
+			return []Turn{{AuthorUsername: "cgbot", Text: "BTC is $1"}}, errors.New("tweet 1: status 404")
+		},
+	}
+	turns := h.history(context.Background(), types.Mention{TweetID: "3", ConversationID: "1"})
+	if len(turns) != 1 || !turns[0].FromBot {
+		t.Fatalf("history = %+v, want the parent found before the failed lookup", turns)
+	}
+	if turns := h.history(context.Background(), types.Mention{TweetID: "1", ConversationID: "1"}); turns != nil {
+		t.Fatalf("history of a thread root = %+v", turns)
+	}
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import "sync/atomic"
+
+// Settings are the parts of the pipeline that may change while the bot runs,
+// e.g. on a config reload.
+type Settings struct {
+	// RequireApproval stores replies as drafts in the Store for review instead of posting them.
+	RequireApproval bool
+	// Instructions are appended to every question sent to the agent or MCP tool.
+	Instructions string
+	// BotPosts has the agent only answer; the bot then posts the answer under
+	// the mention itself through Reply.
+	BotPosts bool
+	// AgentTools restricts the CoinGecko tools the agent may call; empty allows all.
+	AgentTools []string
+}
+
+// LiveSettings holds the current Settings. The handler is copied into the
+// activity, admin and dashboard handlers, so they share it by pointer.
+type LiveSettings struct {
+	v atomic.Pointer[Settings]
+}
+
+// NewLiveSettings returns LiveSettings holding s.
+func NewLiveSettings(s Settings) *LiveSettings {
+	l := &LiveSettings{}
+	l.Store(s)
+	return l
+}
+
+// Load returns the current settings; a nil LiveSettings has the zero Settings.
+func (l *LiveSettings) Load() Settings {
+	if l == nil {
+		return Settings{}
+	}
+	if s := l.v.Load(); s != nil {
+		return *s
+	}
+	return Settings{}
+}
+
+// Store replaces the settings for mentions processed from now on.
+func (l *LiveSettings) Store(s Settings) {
+	l.v.Store(&s)
+}
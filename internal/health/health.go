@@ -0,0 +1,173 @@
+// Package health runs the dependency checks behind GET /readyz.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+
+	mcpclient "cg-mentions-bot/internal/mcp"
+)
+
+// DefaultTimeout bounds a single check when Checker.Timeout is zero.
+const DefaultTimeout = 5 * time.Second
+
+// Check probes one dependency. Run returns a short detail on success.
+type Check struct {
+	Name string
+	// Critical checks make the bot not ready when they fail; the others only
+	// degrade the report.
+	Critical bool
+	Run      func(ctx context.Context) (string, error)
+}
+
+// Result is the outcome of one Check.
+type Result struct {
+	Name      string  `json:"name"`
+	OK        bool    `json:"ok"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Detail    string  `json:"detail,omitempty"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the readiness of all dependencies. Status is "ok", "degraded"
+// (only non-critical checks failed) or "unavailable".
+type Report struct {
+	Ready  bool     `json:"ready"`
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Checker runs a fixed set of checks concurrently.
+type Checker struct {
+	Checks []Check
+	// Timeout bounds each check; zero means DefaultTimeout.
+	Timeout time.Duration
+}
+
+// Run executes all checks and summarizes them, keeping the checks' order.
+func (c *Checker) Run(ctx context.Context) Report {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	results := make([]Result, len(c.Checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.Checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			detail, err := chk.Run(cctx)
+			r := Result{
+				Name:      chk.Name,
+				OK:        err == nil,
+				Critical:  chk.Critical,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+				Detail:    detail,
+			}
+			if err != nil {
+				r.Error = err.Error()
+			}
+			results[i] = r
+		}()
+	}
+	wg.Wait()
+
+	rep := Report{Ready: true, Status: "ok", Checks: results}
+	for _, r := range results {
+		switch {
+		case r.OK:
+		case r.Critical:
+			rep.Ready, rep.Status = false, "unavailable"
+		case rep.Ready:
+			rep.Status = "degraded"
+		}
+	}
+	return rep
+}
+
+// ServeHTTP writes the report as JSON with 200 when ready and 503 otherwise.
+func (c *Checker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rep := c.Run(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if rep.Ready {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(rep)
+}
+
+// Executable checks that path (or a command on PATH) resolves to an executable file.
+func Executable(name, path string) Check {
+	return Check{Name: name, Critical: true, Run: func(context.Context) (string, error) {
+		resolved, err := exec.LookPath(path)
+		if err != nil {
+			return "", err
+		}
+		return resolved, nil
+	}}
+}
+
+// MCP checks that the streamable HTTP MCP server at url answers initialize and
+// tools/list and exposes at least one tool (and want, when given).
+func MCP(name, url string, want ...string) Check {
+	return Check{Name: name, Critical: true, Run: func(ctx context.Context) (string, error) {
+		if url == "" {
+			return "", errors.New("URL not configured")
+		}
+		tools, err := mcpclient.ListToolsHTTP(ctx, url)
+		if err != nil {
+			return "", err
+		}
+		if len(tools) == 0 {
+			return "", errors.New("server lists no tools")
+		}
+		have := make(map[string]bool, len(tools))
+		for _, t := range tools {
+			have[t.Name] = true
+		}
+		for _, w := range want {
+			if !have[w] {
+				return "", fmt.Errorf("tool %s not listed", w)
+			}
+		}
+		return fmt.Sprintf("%d tools", len(tools)), nil
+	}}
+}
+
+// Configured checks that the settings are non-empty, without revealing them.
+// values maps each setting's name to its configured value.
+func Configured(name string, values map[string]string) Check {
+	return Check{Name: name, Critical: true, Run: func(context.Context) (string, error) {
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if values[k] == "" {
+				return "", fmt.Errorf("%s is not set", k)
+			}
+		}
+		return "set", nil
+	}}
+}
+
+// Func wraps a probe that only reports an error, such as a store ping.
+func Func(name string, critical bool, probe func() error) Check {
+	return Check{Name: name, Critical: critical, Run: func(context.Context) (string, error) {
+		return "", probe()
+	}}
+}
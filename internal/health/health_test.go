@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func okCheck(name string) Check {
+	return Check{Name: name, Critical: true, Run: func(context.Context) (string, error) { return "fine", nil }}
+}
+
+func TestCheckerReportsEachCheck(t *testing.T) {
+	c := &Checker{
+		Timeout: 50 * time.Millisecond,
+		Checks: []Check{
+			okCheck("store"),
+			{Name: "xmcp", Critical: true, Run: func(context.Context) (string, error) { return "", errors.New("connection refused") }},
+			{Name: "cgproxy", Critical: true, Run: func(ctx context.Context) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			}},
+		},
+	}
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("checks took %v, want them bounded by the timeout", took)
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	var rep Report
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if rep.Ready || rep.Status != "unavailable" || len(rep.Checks) != 3 {
+		t.Fatalf("report = %+v", rep)
+	}
+	want := []struct {
+		name, err string
+		ok        bool
+	}{
+		{"store", "", true},
+		{"xmcp", "connection refused", false},
+		{"cgproxy", "deadline exceeded", false},
+	}
+	for i, w := range want {
+		got := rep.Checks[i]
+		if got.Name != w.name || got.OK != w.ok || !strings.Contains(got.Error, w.err) {
+			t.Errorf("check %d = %+v, want %s ok=%v error containing %q", i, got, w.name, w.ok, w.err)
+		}
+	}
+}
+
+func TestCheckerDegradedOnNonCriticalFailure(t *testing.T) {
+	c := &Checker{Checks: []Check{
+		okCheck("store"),
+		{Name: "coins_catalog", Run: func(context.Context) (string, error) { return "", errors.New("not loaded") }},
+	}}
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var rep Report
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || !rep.Ready || rep.Status != "degraded" {
+		t.Fatalf("status %d, report %+v, want 200 degraded", w.Code, rep)
+	}
+}
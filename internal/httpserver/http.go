@@ -3,13 +3,89 @@ package httpserver
 import (
 	"net/http"
 
+	"cg-mentions-bot/internal/dashboard"
 	"cg-mentions-bot/internal/handlers"
+	"cg-mentions-bot/internal/health"
+	"cg-mentions-bot/internal/metrics"
+	"cg-mentions-bot/internal/tracing"
 
 	"github.com/go-chi/chi/v5"
 )
 
-// NewServer creates a simple HTTP server with health and mentions endpoints.
-func NewServer(port string, h handlers.MentionsHandler) *http.Server {
+// Option registers additional routes on the server.
+type Option func(r chi.Router)
+
+// WithActivity mounts X's Account Activity API webhook at GET/POST path.
+func WithActivity(path string, a handlers.ActivityHandler) Option {
+	return func(r chi.Router) {
+		r.Get(path, a.CRC)
+		r.With(tracing.Handler).Post(path, a.Events)
+	}
+}
+
+// WithDrafts mounts the reply approval endpoints under /admin/drafts, guarded by adminToken.
+func WithDrafts(adminToken string, d handlers.DraftsHandler) Option {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.AdminAuth(adminToken))
+			r.Get("/admin/drafts", d.List)
+			r.Get("/admin/drafts/{id}", d.Get)
+			r.Put("/admin/drafts/{id}", d.Edit)
+			r.Post("/admin/drafts/{id}/approve", d.Approve)
+			r.Post("/admin/drafts/{id}/reject", d.Reject)
+		})
+	}
+}
+
+// WithDeadLetters mounts GET /admin/dead-letters and POST /mentions/{tweet_id}/retry,
+// guarded by adminToken.
+func WithDeadLetters(adminToken string, d handlers.DeadLettersHandler) Option {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.AdminAuth(adminToken))
+			r.Get("/admin/dead-letters", d.List)
+			r.Post("/mentions/{tweet_id}/retry", d.Retry)
+		})
+	}
+}
+
+// WithHistory mounts the mention history and replay endpoints under
+// /admin/mentions, guarded by adminToken.
+func WithHistory(adminToken string, hh handlers.HistoryHandler) Option {
+	return func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.AdminAuth(adminToken))
+			r.Get("/admin/mentions", hh.List)
+			r.Get("/admin/mentions/{tweet_id}", hh.Get)
+			r.With(tracing.Handler).Post("/admin/mentions/{tweet_id}/rerun", hh.Rerun)
+			r.Post("/admin/mentions/{tweet_id}/repost", hh.Repost)
+		})
+	}
+}
+
+// WithDashboard serves the embedded dashboard at /dashboard/ and mounts its
+// ask box at POST /admin/ask, guarded by adminToken. The page itself is
+// static; it calls the admin API with the token the operator enters.
+func WithDashboard(adminToken string, d handlers.DashboardHandler) Option {
+	return func(r chi.Router) {
+		r.Get("/dashboard", http.RedirectHandler("/dashboard/", http.StatusMovedPermanently).ServeHTTP)
+		r.Handle("/dashboard/*", http.StripPrefix("/dashboard", dashboard.Handler()))
+		r.Group(func(r chi.Router) {
+			r.Use(handlers.AdminAuth(adminToken))
+			r.With(tracing.Handler).Post("/admin/ask", d.Ask)
+		})
+	}
+}
+
+// WithReadiness mounts GET /readyz, reporting each dependency checked by c.
+func WithReadiness(c *health.Checker) Option {
+	return func(r chi.Router) {
+		r.Method(http.MethodGet, "/readyz", c)
+	}
+}
+
+// NewServer creates a simple HTTP server with health, metrics, mentions and job status endpoints.
+func NewServer(port string, h handlers.MentionsHandler, opts ...Option) *http.Server {
 	r := chi.NewRouter()
 
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
@@ -18,7 +94,21 @@ func NewServer(port string, h handlers.MentionsHandler) *http.Server {
 		_, _ = w.Write([]byte(`{"ok":true}`))
 	})
 
-	r.Post("/mentions", h.Handle)
+	r.With(tracing.Handler).Post("/mentions", h.Handle)
+	r.Handle("/metrics", metrics.Handler())
+
+	if h.Queue != nil {
+		jh := handlers.JobsHandler{Queue: h.Queue}
+		r.Group(func(r chi.Router) {
+			r.Use(h.JobsAuth)
+			r.Get("/jobs", jh.List)
+			r.Get("/jobs/{id}", jh.Get)
+		})
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
 
 	return &http.Server{
 		Addr:    ":" + port,
@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"strings"
+	"unicode"
+)
+
+var stopwords = map[string]map[string]bool{
+	"en": set("the", "what", "whats", "is", "are", "price", "of", "how", "much", "in", "and", "today", "for", "vs", "now", "current", "tell", "me", "about", "which", "will", "does", "many"),
+	"tr": set("ne", "kaç", "kac", "fiyat", "fiyatı", "fiyati", "nedir", "mi", "mı", "mu", "mü", "ve", "bu", "için", "icin", "kadar", "bugün", "bugun", "şu", "piyasa", "değeri", "degeri", "ile", "nasıl", "nasil", "hangi", "var", "yok", "lira", "olur", "oldu", "değer", "ederi"),
+	"es": set("el", "la", "de", "que", "qué", "precio", "cuánto", "cuanto", "cuál", "cual", "es", "y", "en", "del", "los", "las", "hoy", "está", "esta", "para", "por", "valor", "dólares", "dolares", "euros", "cómo", "como", "ahora", "sobre"),
+	"pt": set("o", "a", "de", "que", "preço", "preco", "quanto", "qual", "é", "e", "em", "do", "da", "os", "as", "hoje", "está", "para", "por", "valor", "reais", "você", "voce", "não", "nao", "mais", "ao", "agora", "sobre", "como"),
+}
+
+var letters = map[string]string{
+	"tr": "ğışİĞŞı",
+	"es": "ñ¿¡",
+	"pt": "ãõâêô",
+}
+
+// Detect guesses the language of short tweet text among en, tr, es and pt.
+// It returns "" when there is not enough signal.
+func Detect(text string) string {
+	lower := strings.ToLower(text)
+	scores := map[string]int{}
+	for lang, chars := range letters {
+		for _, r := range lower {
+			if strings.ContainsRune(chars, r) {
+				scores[lang] += 2
+			}
+		}
+	}
+	words := strings.FieldsFunc(lower, func(r rune) bool { return !unicode.IsLetter(r) && r != '\'' })
+	for _, w := range words {
+		w = strings.ReplaceAll(w, "'", "")
+		for lang, sw := range stopwords {
+			if sw[w] {
+				scores[lang]++
+			}
+		}
+	}
+
+	best, bestScore, tie := "", 0, false
+	for _, lang := range []string{"en", "tr", "es", "pt"} {
+		switch s := scores[lang]; {
+		case s > bestScore:
+			best, bestScore, tie = lang, s, false
+		case s == bestScore && s > 0:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
+
+// Normalize maps a tweet language tag to a base language code, returning ""
+// for X's undetermined or non-linguistic tags (und, qme, qht, zxx, ...).
+func Normalize(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i > 0 {
+		tag = tag[:i]
+	}
+	if tag == "" || tag == "und" || tag == "zxx" || (len(tag) == 3 && tag[0] == 'q') {
+		return ""
+	}
+	return tag
+}
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
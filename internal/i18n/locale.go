@@ -0,0 +1,114 @@
+package i18n
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Locale describes how numbers and currency amounts are written in a language.
+type Locale struct {
+	Lang    string
+	Name    string
+	Group   string
+	Decimal string
+	// SymbolAfter places the currency symbol after the amount ("67.000,50 $").
+	SymbolAfter bool
+	// USD is how the dollar sign is written ("$", "US$").
+	USD string
+}
+
+var locales = map[string]Locale{
+	"en": {Lang: "en", Name: "English", Group: ",", Decimal: ".", USD: "$"},
+	"tr": {Lang: "tr", Name: "Turkish", Group: ".", Decimal: ",", USD: "$"},
+	"es": {Lang: "es", Name: "Spanish", Group: ".", Decimal: ",", SymbolAfter: true, USD: "US$"},
+	"pt": {Lang: "pt", Name: "Portuguese", Group: ".", Decimal: ",", USD: "US$"},
+	"de": {Lang: "de", Name: "German", Group: ".", Decimal: ",", SymbolAfter: true, USD: "$"},
+	"fr": {Lang: "fr", Name: "French", Group: " ", Decimal: ",", SymbolAfter: true, USD: "$US"},
+	"it": {Lang: "it", Name: "Italian", Group: ".", Decimal: ",", SymbolAfter: true, USD: "USD"},
+}
+
+// LocaleFor returns the locale for lang and whether it is known.
+func LocaleFor(lang string) (Locale, bool) {
+	l, ok := locales[Normalize(lang)]
+	return l, ok
+}
+
+// Example renders a sample amount in the locale, for prompting.
+func (l Locale) Example() string {
+	return l.Localize("$67,123.45")
+}
+
+var amountRe = regexp.MustCompile(`([$€£])?(\d{1,3}(?:,\d{3})+|\d+)(\.\d+)?`)
+
+// Localize rewrites English-formatted amounts in s ("$67,123.45", "1.5%") with
+// the locale's separators and currency placement. Plain integers without a
+// currency symbol, separators or decimals are left untouched, and so are
+// numbers inside words or versions ("v1.2", "web3", "1.2.3").
+func (l Locale) Localize(s string) string {
+	if l.Lang == "en" || l.Lang == "" {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, idx := range amountRe.FindAllStringSubmatchIndex(s, -1) {
+		start, end := idx[0], idx[1]
+		sym := group(s, idx, 1)
+		if sym == "" && partOfWord(s, start, end) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(l.amount(sym, group(s, idx, 2), group(s, idx, 3)))
+		last = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// amount renders one matched amount; it returns the English form when there
+// is nothing to localize.
+func (l Locale) amount(sym, intPart, frac string) string {
+	if sym == "" && frac == "" && !strings.Contains(intPart, ",") {
+		return intPart
+	}
+	num := strings.ReplaceAll(intPart, ",", l.Group)
+	if frac != "" {
+		num += l.Decimal + frac[1:]
+	}
+	if sym == "" {
+		return num
+	}
+	if sym == "$" {
+		sym = l.USD
+	}
+	if l.SymbolAfter {
+		return num + " " + sym
+	}
+	if utf8.RuneCountInString(sym) > 1 {
+		return sym + " " + num
+	}
+	return sym + num
+}
+
+// partOfWord reports whether the number s[start:end] is glued to a word or
+// version: preceded by a letter, digit or dot, or followed by ".<digit>".
+func partOfWord(s string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:start])
+		if r == '.' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return end+1 < len(s) && s[end] == '.' && s[end+1] >= '0' && s[end+1] <= '9'
+}
+
+func group(s string, idx []int, n int) string {
+	if idx[2*n] < 0 {
+		return ""
+	}
+	return s[idx[2*n]:idx[2*n+1]]
+}
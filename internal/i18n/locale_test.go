@@ -0,0 +1,53 @@
+package i18n
+
+import "testing"
+
+func TestLocalize(t *testing.T) {
+	tests := []struct {
+		lang, in, want string
+	}{
+		{"en", "BTC is $67,123.45", "BTC is $67,123.45"},
+		{"tr", "BTC $67,123.45 seviyesinde, %2.5 arttı", "BTC $67.123,45 seviyesinde, %2,5 arttı"},
+		{"es", "BTC está en $67,123.45", "BTC está en 67.123,45 US$"},
+		{"pt", "Volume: $1,200,000", "Volume: US$ 1.200.000"},
+		{"fr", "Le BTC vaut €60,000.5", "Le BTC vaut 60\u202f000,5 €"},
+		{"de", "Rang 1 von 10000 Coins", "Rang 1 von 10000 Coins"},
+		{"tr", "Market cap $1.2B, rank #1", "Market cap $1,2B, rank #1"},
+		// Versions and names with digits are not amounts.
+		{"tr", "Uniswap v1.2 ve v3 havuzları", "Uniswap v1.2 ve v3 havuzları"},
+		{"es", "Release 1.2.3 de web3.0", "Release 1.2.3 de web3.0"},
+		{"pt", "ETH2.0 caiu 1.5% hoje", "ETH2.0 caiu 1,5% hoje"},
+	}
+	for _, tt := range tests {
+		l, ok := LocaleFor(tt.lang)
+		if !ok {
+			t.Fatalf("LocaleFor(%q) not found", tt.lang)
+		}
+		if got := l.Localize(tt.in); got != tt.want {
+			t.Errorf("%s: Localize(%q) = %q, want %q", tt.lang, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := map[string]string{
+		"what is the price of btc today?": "en",
+		"btc fiyatı ne kadar?":            "tr",
+		"¿cuánto cuesta el bitcoin hoy?":  "es",
+		"qual o preço do bitcoin hoje?":   "pt",
+		"$BTC":                            "",
+	}
+	for in, want := range tests {
+		if got := Detect(in); got != want {
+			t.Errorf("Detect(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{"pt-BR": "pt", "EN": "en", "und": "", "qme": "", "zxx": "", "": ""} {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"cg-mentions-bot/internal/types"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Status is the lifecycle state of a job.
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusPosted  Status = "posted"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+	// StatusPendingApproval means the reply was drafted and awaits review.
+	StatusPendingApproval Status = "pending_approval"
+)
+
+var (
+	// ErrQueueFull is returned by Enqueue when the pending buffer is at capacity.
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrQueueClosed is returned by Enqueue once Shutdown has been called.
+	ErrQueueClosed = errors.New("job queue is shutting down")
+)
+
+// ProcessFunc handles a single mention and reports its outcome.
+type ProcessFunc func(ctx context.Context, m types.Mention) types.MentionResult
+
+// Job tracks one mention through the worker pool.
+type Job struct {
+	ID         string               `json:"id"`
+	BatchID    string               `json:"batch_id"`
+	TweetID    string               `json:"tweet_id"`
+	Status     Status               `json:"status"`
+	Result     *types.MentionResult `json:"result,omitempty"`
+	CreatedAt  time.Time            `json:"created_at"`
+	StartedAt  *time.Time           `json:"started_at,omitempty"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+
+	mention types.Mention
+	// parent is the span that enqueued the job, so its processing joins the same trace.
+	parent trace.SpanContext
+}
+
+// Queue runs mentions on a fixed pool of workers and keeps their status in memory.
+type Queue struct {
+	// Timeout bounds a single job; zero means no limit.
+	Timeout time.Duration
+	// Retain is the number of finished jobs kept for status lookups.
+	Retain int
+
+	workers int
+	process ProcessFunc
+	pending chan *Job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	finished []string
+	closed   bool
+	// leftover collects mentions interrupted or never started after Shutdown's deadline.
+	leftover []types.Mention
+}
+
+// NewQueue constructs a Queue with the given number of workers and pending buffer size.
+// Call Start to begin processing.
+func NewQueue(workers, size int, process ProcessFunc) *Queue {
+	if workers <= 0 {
+		workers = 1
+	}
+	if size <= 0 {
+		size = 100
+	}
+	return &Queue{
+		Retain:  1000,
+		workers: workers,
+		process: process,
+		pending: make(chan *Job, size),
+		jobs:    make(map[string]*Job),
+	}
+}
+
+// Start launches the workers. They exit when ctx is cancelled, abandoning
+// running jobs; use Shutdown to stop gracefully instead.
+func (q *Queue) Start(ctx context.Context) {
+	q.ctx, q.cancel = context.WithCancel(ctx)
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+}
+
+// Shutdown stops accepting jobs and waits for queued and running jobs to finish.
+// If ctx expires first, running jobs are cancelled and Shutdown returns the
+// mentions that were interrupted or never started, so they can be persisted.
+func (q *Queue) Shutdown(ctx context.Context) []types.Mention {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.pending)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		if q.cancel != nil {
+			q.cancel()
+		}
+		<-done
+	}
+
+	for j := range q.pending {
+		q.leftover = append(q.leftover, j.mention)
+	}
+	return q.leftover
+}
+
+// NewBatchID returns an identifier for grouping jobs enqueued together.
+func NewBatchID() string { return newID() }
+
+// Enqueue schedules a mention and returns a snapshot of the queued job. The
+// span in ctx, if any, becomes the parent of the job's processing.
+func (q *Queue) Enqueue(ctx context.Context, batchID string, m types.Mention) (Job, error) {
+	j := &Job{
+		ID:        newID(),
+		BatchID:   batchID,
+		TweetID:   m.TweetID,
+		Status:    StatusQueued,
+		CreatedAt: time.Now().UTC(),
+		mention:   m,
+		parent:    trace.SpanContextFromContext(ctx),
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return Job{}, ErrQueueClosed
+	}
+	select {
+	case q.pending <- j:
+		q.jobs[j.ID] = j
+		return *j, nil
+	default:
+		return Job{}, ErrQueueFull
+	}
+}
+
+// Get returns a snapshot of the job with the given id.
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+// List returns snapshots of jobs in the given batch, or of all known jobs when
+// batchID is empty, oldest first.
+func (q *Queue) List(batchID string) []Job {
+	q.mu.Lock()
+	out := make([]Job, 0)
+	for _, j := range q.jobs {
+		if batchID == "" || j.BatchID == batchID {
+			out = append(out, *j)
+		}
+	}
+	q.mu.Unlock()
+	sort.Slice(out, func(a, b int) bool { return out[a].CreatedAt.Before(out[b].CreatedAt) })
+	return out
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case j, ok := <-q.pending:
+			if !ok {
+				return
+			}
+			if q.ctx.Err() != nil {
+				q.interrupt(j)
+				return
+			}
+			q.run(q.ctx, j)
+		}
+	}
+}
+
+func (q *Queue) interrupt(j *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.leftover = append(q.leftover, j.mention)
+}
+
+func (q *Queue) run(ctx context.Context, j *Job) {
+	now := time.Now().UTC()
+	q.mu.Lock()
+	j.Status = StatusRunning
+	j.StartedAt = &now
+	q.mu.Unlock()
+
+	jctx := ctx
+	if j.parent.IsValid() {
+		jctx = trace.ContextWithRemoteSpanContext(jctx, j.parent)
+	}
+	if q.Timeout > 0 {
+		var cancel context.CancelFunc
+		jctx, cancel = context.WithTimeout(ctx, q.Timeout)
+		defer cancel()
+	}
+	res := q.process(jctx, j.mention)
+
+	done := time.Now().UTC()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	j.Result = &res
+	j.FinishedAt = &done
+	j.Status = statusOf(res)
+	if ctx.Err() != nil && j.Status == StatusFailed {
+		// Cancelled by Shutdown: hand the mention back instead of losing it.
+		// Mentions that got through (posted, skipped, drafted) are done.
+		q.leftover = append(q.leftover, j.mention)
+	}
+	q.finished = append(q.finished, j.ID)
+	for q.Retain > 0 && len(q.finished) > q.Retain {
+		delete(q.jobs, q.finished[0])
+		q.finished = q.finished[1:]
+	}
+}
+
+func statusOf(res types.MentionResult) Status {
+	if res.Skipped != "" {
+		return StatusSkipped
+	}
+	if res.PendingApproval {
+		return StatusPendingApproval
+	}
+	if res.Posted {
+		return StatusPosted
+	}
+	return StatusFailed
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
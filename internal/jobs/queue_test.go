@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cg-mentions-bot/internal/types"
+)
+
+func TestShutdownWithoutStart(t *testing.T) {
+	q := NewQueue(1, 10, func(context.Context, types.Mention) types.MentionResult {
+		t.Error("process called without Start")
+		return types.MentionResult{}
+	})
+	if _, err := q.Enqueue(context.Background(), NewBatchID(), types.Mention{TweetID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	left := q.Shutdown(ctx)
+	if len(left) != 1 || left[0].TweetID != "1" {
+		t.Fatalf("leftover = %+v, want the queued mention", left)
+	}
+	if _, err := q.Enqueue(context.Background(), NewBatchID(), types.Mention{TweetID: "2"}); err != ErrQueueClosed {
+		t.Fatalf("Enqueue after Shutdown = %v, want ErrQueueClosed", err)
+	}
+}
+
+func TestShutdownHandsBackOnlyUnfinishedJobs(t *testing.T) {
+	started := make(chan struct{}, 2)
+	q := NewQueue(2, 10, func(ctx context.Context, m types.Mention) types.MentionResult {
+		started <- struct{}{}
+		<-ctx.Done()
+		if m.TweetID == "posted" {
+			// The reply went out just as the deadline hit.
+			return types.MentionResult{TweetID: m.TweetID, Posted: true}
+		}
+		return types.MentionResult{TweetID: m.TweetID, Error: ctx.Err().Error()}
+	})
+	q.Start(context.Background())
+	for _, id := range []string{"posted", "interrupted"} {
+		if _, err := q.Enqueue(context.Background(), NewBatchID(), types.Mention{TweetID: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-started
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	left := q.Shutdown(ctx)
+	if len(left) != 1 || left[0].TweetID != "interrupted" {
+		t.Fatalf("leftover = %+v, want only the interrupted mention", left)
+	}
+}
+
+func TestEnqueueFullQueue(t *testing.T) {
+	q := NewQueue(1, 1, func(context.Context, types.Mention) types.MentionResult { return types.MentionResult{} })
+	if _, err := q.Enqueue(context.Background(), "b", types.Mention{TweetID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(context.Background(), "b", types.Mention{TweetID: "2"}); err != ErrQueueFull {
+		t.Fatalf("Enqueue on a full queue = %v, want ErrQueueFull", err)
+	}
+	if jobs := q.List("b"); len(jobs) != 1 || jobs[0].TweetID != "1" {
+		t.Fatalf("List = %+v, want only the accepted job", jobs)
+	}
+}
+
+func TestJobStatusTransitions(t *testing.T) {
+	release := make(chan struct{})
+	running := make(chan struct{})
+	q := NewQueue(1, 10, func(_ context.Context, m types.Mention) types.MentionResult {
+		if m.TweetID == "block" {
+			running <- struct{}{}
+			<-release
+		}
+		switch m.TweetID {
+		case "skip":
+			return types.MentionResult{TweetID: m.TweetID, Skipped: "ignored"}
+		case "draft":
+			return types.MentionResult{TweetID: m.TweetID, PendingApproval: true}
+		case "fail":
+			return types.MentionResult{TweetID: m.TweetID, Error: "boom"}
+		}
+		return types.MentionResult{TweetID: m.TweetID, Posted: true}
+	})
+
+	batch := NewBatchID()
+	ids := map[string]string{}
+	for _, id := range []string{"block", "skip", "draft", "fail"} {
+		j, err := q.Enqueue(context.Background(), batch, types.Mention{TweetID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if j.Status != StatusQueued || j.StartedAt != nil {
+			t.Fatalf("enqueued job = %+v, want queued", j)
+		}
+		ids[id] = j.ID
+	}
+	q.Start(context.Background())
+
+	<-running
+	if j, _ := q.Get(ids["block"]); j.Status != StatusRunning || j.StartedAt == nil || j.FinishedAt != nil {
+		t.Fatalf("running job = %+v", j)
+	}
+	if j, _ := q.Get(ids["skip"]); j.Status != StatusQueued {
+		t.Fatalf("waiting job = %+v, want still queued behind the single worker", j)
+	}
+	close(release)
+	q.Shutdown(context.Background())
+
+	for id, want := range map[string]Status{"block": StatusPosted, "skip": StatusSkipped, "draft": StatusPendingApproval, "fail": StatusFailed} {
+		j, ok := q.Get(ids[id])
+		if !ok || j.Status != want || j.Result == nil || j.FinishedAt == nil {
+			t.Errorf("job %s = %+v, want %s with a result", id, j, want)
+		}
+	}
+	if jobs := q.List(batch); len(jobs) != 4 {
+		t.Errorf("List = %+v, want the whole batch", jobs)
+	}
+}
+
+func TestFinishedJobsAreRetained(t *testing.T) {
+	q := NewQueue(1, 10, func(_ context.Context, m types.Mention) types.MentionResult {
+		return types.MentionResult{TweetID: m.TweetID, Posted: true}
+	})
+	q.Retain = 2
+	var ids []string
+	for _, id := range []string{"1", "2", "3"} {
+		j, err := q.Enqueue(context.Background(), "b", types.Mention{TweetID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, j.ID)
+	}
+	q.Start(context.Background())
+	q.Shutdown(context.Background())
+
+	if _, ok := q.Get(ids[0]); ok {
+		t.Error("oldest finished job was kept beyond Retain")
+	}
+	for _, id := range ids[1:] {
+		if _, ok := q.Get(id); !ok {
+			t.Errorf("job %s was dropped", id)
+		}
+	}
+	if n := len(q.List("")); n != 2 {
+		t.Errorf("List has %d jobs, want 2", n)
+	}
+}
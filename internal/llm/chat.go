@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"cg-mentions-bot/internal/metrics"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// NewChat returns a function that sends a single-turn prompt to an
+// OpenAI-compatible chat completions endpoint and returns the reply text.
+func NewChat(baseURL, apiKey, model string) func(ctx context.Context, prompt string) (string, error) {
+	client := retryablehttp.NewClient()
+	client.Logger = nil
+	client.RetryMax = 2
+
+	chat := func(ctx context.Context, prompt string) (string, error) {
+		payload, err := json.Marshal(map[string]any{
+			"model":       model,
+			"temperature": 0,
+			"messages": []map[string]string{
+				{"role": "user", "content": prompt},
+			},
+		})
+		if err != nil {
+			return "", err
+		}
+		url := strings.TrimRight(baseURL, "/") + "/chat/completions"
+		req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			b, _ := io.ReadAll(resp.Body)
+			return "", fmt.Errorf("llm request failed: status %d: %s", resp.StatusCode, string(b))
+		}
+		var out struct {
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return "", err
+		}
+		if len(out.Choices) == 0 {
+			return "", errors.New("llm returned no choices")
+		}
+		return strings.TrimSpace(out.Choices[0].Message.Content), nil
+	}
+	return func(ctx context.Context, prompt string) (string, error) {
+		out, err := chat(ctx, prompt)
+		if err != nil {
+			metrics.UpstreamErrors.WithLabelValues("llm").Inc()
+		}
+		return out, err
+	}
+}
@@ -7,8 +7,12 @@ import (
 	"os"
 	"strings"
 
+	"cg-mentions-bot/internal/tracing"
+
 	mcpclient "github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
 	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Ask uses the official MCP stdio client to initialize and call a tool, then
@@ -41,16 +45,95 @@ func Call(ctx context.Context, mcpCmd string, tool string, args map[string]inter
 		return "", err
 	}
 
-	res, err := c.CallTool(ctx, mcp.CallToolRequest{
+	res, err := callTool(ctx, c, tool, args)
+	if err != nil {
+		return "", err
+	}
+	return textContent(res)
+}
+
+// CallHTTP calls a tool on a streamable HTTP MCP server (such as xmcp or cgproxy)
+// and returns concatenated text content. Tool errors are returned as errors.
+func CallHTTP(ctx context.Context, url string, tool string, args map[string]interface{}) (text string, err error) {
+	ctx, span := tracing.Start(ctx, "mcp.call "+tool, attribute.String("mcp.tool", tool), attribute.String("mcp.url", url))
+	defer func() { tracing.End(span, err) }()
+
+	c, err := dialHTTP(ctx, url)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	res, err := callTool(ctx, c, tool, args)
+	if err != nil {
+		return "", err
+	}
+	if res != nil && res.IsError {
+		text, _ := textContent(res)
+		return "", fmt.Errorf("tool %s failed: %s", tool, text)
+	}
+	return textContent(res)
+}
+
+// ListToolsHTTP initializes a session with a streamable HTTP MCP server and
+// returns its tools.
+func ListToolsHTTP(ctx context.Context, url string) ([]mcp.Tool, error) {
+	c, err := dialHTTP(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	res, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Tools, nil
+}
+
+// dialHTTP starts and initializes a client for a streamable HTTP MCP server.
+// The caller must Close it.
+func dialHTTP(ctx context.Context, url string) (*mcpclient.Client, error) {
+	// The trace context travels to the server as traceparent headers.
+	c, err := mcpclient.NewStreamableHttpClient(url, transport.WithHTTPHeaderFunc(tracing.Headers))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Start(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	_, err = c.Initialize(ctx, mcp.InitializeRequest{
+		Request: mcp.Request{Method: string(mcp.MethodInitialize)},
+		Params: mcp.InitializeParams{
+			ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
+			Capabilities:    mcp.ClientCapabilities{},
+			ClientInfo: mcp.Implementation{
+				Name:    "cg-mentions-bot",
+				Version: "0.1.0",
+			},
+		},
+	})
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func callTool(ctx context.Context, c *mcpclient.Client, tool string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	return c.CallTool(ctx, mcp.CallToolRequest{
 		Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
 		Params: mcp.CallToolParams{
 			Name:      tool,
 			Arguments: args,
 		},
 	})
-	if err != nil {
-		return "", err
-	}
+}
+
+// textContent concatenates the text items of a tool result.
+func textContent(res *mcp.CallToolResult) (string, error) {
 	if res == nil || len(res.Content) == 0 {
 		return "", errors.New("empty tool result")
 	}
@@ -0,0 +1,135 @@
+// Package metrics defines the Prometheus metrics shared by the bot, xmcp and
+// cgproxy. Each binary serves the ones it records on /metrics.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "cgbot"
+
+var (
+	// MentionsReceived counts mentions accepted per source: webhook, poller, activity.
+	MentionsReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mentions_received_total",
+		Help:      "Mentions received, by source.",
+	}, []string{"source"})
+
+	// MentionsProcessed counts finished mentions by outcome (posted, drafted,
+	// skipped, failed, dry_run) and reason (skip reason or failed stage).
+	MentionsProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mentions_processed_total",
+		Help:      "Mentions processed, by outcome and reason.",
+	}, []string{"outcome", "reason"})
+
+	// AgentRunSeconds observes one agent run per mention.
+	AgentRunSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "agent_run_seconds",
+		Help:      "Agent run latency, by outcome.",
+		Buckets:   []float64{1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+	}, []string{"outcome"})
+
+	// AgentToolCalls counts the tools the agent reported using.
+	AgentToolCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "agent_tool_calls_total",
+		Help:      "Tool calls reported by the agent, by tool.",
+	}, []string{"tool"})
+
+	// ToolCalls and ToolCallSeconds are recorded by the MCP servers for each tool call.
+	ToolCalls = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tool_calls_total",
+		Help:      "MCP tool calls served, by server, tool and outcome.",
+	}, []string{"server", "tool", "outcome"})
+	ToolCallSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "tool_call_seconds",
+		Help:      "MCP tool call latency, by server and tool.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"server", "tool"})
+
+	// UpstreamErrors counts failed calls to dependencies: coingecko_mcp,
+	// coingecko_api, llm, x_api.
+	UpstreamErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "upstream_errors_total",
+		Help:      "Errors from upstream services, by upstream.",
+	}, []string{"upstream"})
+
+	// XAPIResponses counts X API responses by endpoint and HTTP status code
+	// ("error" when no response was received).
+	XAPIResponses = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "x_api_responses_total",
+		Help:      "X API responses, by endpoint and status code.",
+	}, []string{"endpoint", "code"})
+)
+
+// Handler serves the default registry in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Outcome labels an error for histograms and counters.
+func Outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
+
+// ToolMiddleware records ToolCalls and ToolCallSeconds for every tool served by
+// an MCP server named serverName. Tool results flagged as errors count as errors.
+func ToolMiddleware(serverName string) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			start := time.Now()
+			res, err := next(ctx, req)
+			ToolCallSeconds.WithLabelValues(serverName, req.Params.Name).Observe(time.Since(start).Seconds())
+			outcome := Outcome(err)
+			if res != nil && res.IsError {
+				outcome = "error"
+			}
+			ToolCalls.WithLabelValues(serverName, req.Params.Name, outcome).Inc()
+			return res, err
+		}
+	}
+}
+
+// XTransport wraps rt (http.DefaultTransport when nil) so every X API
+// response is counted in XAPIResponses under endpoint.
+func XTransport(endpoint string, rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			XAPIResponses.WithLabelValues(endpoint, "error").Inc()
+			UpstreamErrors.WithLabelValues("x_api").Inc()
+			return resp, err
+		}
+		XAPIResponses.WithLabelValues(endpoint, strconv.Itoa(resp.StatusCode)).Inc()
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			UpstreamErrors.WithLabelValues("x_api").Inc()
+		}
+		return resp, nil
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -0,0 +1,183 @@
+package policy
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"cg-mentions-bot/internal/types"
+)
+
+// Reasons reported when a mention is rejected.
+const (
+	ReasonSelf           = "own_account"
+	ReasonBlocked        = "author_blocked"
+	ReasonNotAllowlisted = "author_not_allowlisted"
+	ReasonRateLimited    = "author_rate_limited"
+)
+
+// Authors decides whose mentions we answer: it ignores our own account, applies
+// block and allow lists (by user ID or username) and per-author quotas.
+// Set the fields before use; change them later with Configure.
+type Authors struct {
+	SelfID       string
+	SelfUsername string
+	// Limit answered mentions per author within Window; zero disables the quota.
+	Limit  int
+	Window time.Duration
+	// AllowOnly restricts answers to allowlisted authors. Allowlisted authors
+	// are never rate limited.
+	AllowOnly bool
+
+	mu    sync.Mutex
+	allow map[string]bool
+	block map[string]bool
+	hits  map[string][]Hit
+	seq   uint64
+	swept time.Time
+}
+
+// Hit is the quota unit taken by one admitted mention. The zero Hit took none.
+type Hit struct {
+	author string
+	seq    uint64
+	at     time.Time
+}
+
+// NewAuthors returns an Authors policy with the given allow and block list entries.
+func NewAuthors(allow, block []string) *Authors {
+	return &Authors{allow: toSet(allow), block: toSet(block), hits: make(map[string][]Hit)}
+}
+
+// Configure replaces the lists and quota while the bot runs. Quota usage
+// recorded so far is kept unless the quota is turned off.
+func (a *Authors) Configure(allow, block []string, allowOnly bool, limit int, window time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.allow, a.block = toSet(allow), toSet(block)
+	a.AllowOnly, a.Limit, a.Window = allowOnly, limit, window
+	if limit <= 0 {
+		clear(a.hits)
+	}
+}
+
+// Admit reports why m must not be answered, or "" when it may. An admitted
+// mention may use one unit of its author's quota, returned as a Hit to pass to
+// Release if the mention ends up not being answered.
+func (a *Authors) Admit(m types.Mention) (Hit, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if reason := a.check(m); reason != "" {
+		return Hit{}, reason
+	}
+	if a.listed(a.allow, m) || a.Limit <= 0 {
+		return Hit{}, ""
+	}
+
+	now := time.Now()
+	a.sweep(now)
+	author := authorKey(m)
+	recent := a.recent(author, now)
+	if len(recent) >= a.Limit {
+		a.hits[author] = recent
+		return Hit{}, ReasonRateLimited
+	}
+	a.seq++
+	h := Hit{author: author, seq: a.seq, at: now}
+	a.hits[author] = append(recent, h)
+	return h, ""
+}
+
+// Check applies the own account, block and allow list rules to m without
+// touching the quota, and reports why m must not be answered, or "".
+func (a *Authors) Check(m types.Mention) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.check(m)
+}
+
+func (a *Authors) check(m types.Mention) string {
+	if (a.SelfID != "" && m.AuthorID == a.SelfID) || (a.SelfUsername != "" && key(m.AuthorUsername) == key(a.SelfUsername)) {
+		return ReasonSelf
+	}
+	if a.listed(a.block, m) {
+		return ReasonBlocked
+	}
+	if a.AllowOnly && !a.listed(a.allow, m) {
+		return ReasonNotAllowlisted
+	}
+	return ""
+}
+
+// Release returns the quota unit h taken by Admit, for mentions that ended up
+// not being answered. Releasing the zero Hit does nothing.
+func (a *Authors) Release(h Hit) {
+	if h.seq == 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hits := a.hits[h.author]
+	for i, x := range hits {
+		if x.seq == h.seq {
+			hits = append(hits[:i], hits[i+1:]...)
+			break
+		}
+	}
+	if len(hits) == 0 {
+		delete(a.hits, h.author)
+	} else {
+		a.hits[h.author] = hits
+	}
+}
+
+// recent returns author's hits inside the window.
+func (a *Authors) recent(author string, now time.Time) []Hit {
+	recent := a.hits[author][:0]
+	for _, h := range a.hits[author] {
+		if now.Sub(h.at) < a.Window {
+			recent = append(recent, h)
+		}
+	}
+	return recent
+}
+
+// sweep drops the authors without recent hits, at most once per window.
+func (a *Authors) sweep(now time.Time) {
+	if now.Sub(a.swept) < a.Window {
+		return
+	}
+	a.swept = now
+	for author := range a.hits {
+		if recent := a.recent(author, now); len(recent) > 0 {
+			a.hits[author] = recent
+		} else {
+			delete(a.hits, author)
+		}
+	}
+}
+
+func (a *Authors) listed(set map[string]bool, m types.Mention) bool {
+	return (m.AuthorID != "" && set[m.AuthorID]) || (m.AuthorUsername != "" && set[key(m.AuthorUsername)])
+}
+
+func authorKey(m types.Mention) string {
+	if m.AuthorID != "" {
+		return m.AuthorID
+	}
+	return key(m.AuthorUsername)
+}
+
+func key(s string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(s), "@"))
+}
+
+func toSet(entries []string) map[string]bool {
+	set := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if k := key(e); k != "" {
+			set[k] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,93 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"cg-mentions-bot/internal/types"
+)
+
+func TestAuthorsLists(t *testing.T) {
+	a := NewAuthors([]string{"@Friend"}, []string{"42"})
+	a.SelfUsername = "NexArb_"
+	a.AllowOnly = true
+	tests := []struct {
+		m    types.Mention
+		want string
+	}{
+		{types.Mention{AuthorUsername: "nexarb_"}, ReasonSelf},
+		{types.Mention{AuthorID: "42", AuthorUsername: "friend"}, ReasonBlocked},
+		{types.Mention{AuthorID: "7", AuthorUsername: "stranger"}, ReasonNotAllowlisted},
+		{types.Mention{AuthorID: "8", AuthorUsername: "FRIEND"}, ""},
+	}
+	for _, tt := range tests {
+		if got := a.Check(tt.m); got != tt.want {
+			t.Errorf("Check(%+v) = %q, want %q", tt.m, got, tt.want)
+		}
+		if _, got := a.Admit(tt.m); got != tt.want {
+			t.Errorf("Admit(%+v) = %q, want %q", tt.m, got, tt.want)
+		}
+	}
+}
+
+func TestAuthorsCheckLeavesQuotaAlone(t *testing.T) {
+	a := NewAuthors(nil, nil)
+	a.Limit, a.Window = 1, time.Hour
+	m := types.Mention{AuthorID: "1"}
+	for i := 0; i < 3; i++ {
+		if reason := a.Check(m); reason != "" {
+			t.Fatalf("Check = %q, want admitted", reason)
+		}
+	}
+	if _, reason := a.Admit(m); reason != "" {
+		t.Fatalf("Admit after Check = %q, want admitted", reason)
+	}
+	if reason := a.Check(m); reason != "" {
+		t.Fatalf("Check over quota = %q, want the quota left to Admit", reason)
+	}
+}
+
+func TestAuthorsReleaseReturnsItsOwnHit(t *testing.T) {
+	a := NewAuthors(nil, nil)
+	a.Limit, a.Window = 2, time.Hour
+	m := types.Mention{AuthorID: "1"}
+
+	first, _ := a.Admit(m)
+	second, _ := a.Admit(m)
+	if _, reason := a.Admit(m); reason != ReasonRateLimited {
+		t.Fatalf("third Admit = %q, want rate limited", reason)
+	}
+	// The first mention failed; releasing it must not touch the second's hit.
+	a.Release(first)
+	a.Release(first)
+	third, reason := a.Admit(m)
+	if reason != "" {
+		t.Fatalf("Admit after Release = %q, want admitted", reason)
+	}
+	if _, reason := a.Admit(m); reason != ReasonRateLimited {
+		t.Fatalf("Admit = %q, want rate limited (second and third hold the quota)", reason)
+	}
+	a.Release(second)
+	a.Release(third)
+	if len(a.hits) != 0 {
+		t.Fatalf("hits = %v, want the author removed", a.hits)
+	}
+	a.Release(Hit{})
+}
+
+func TestAuthorsPrunesExpiredHits(t *testing.T) {
+	a := NewAuthors(nil, nil)
+	a.Limit, a.Window = 1, 10*time.Millisecond
+	for _, id := range []string{"1", "2", "3"} {
+		if _, reason := a.Admit(types.Mention{AuthorID: id}); reason != "" {
+			t.Fatalf("Admit(%s) = %q", id, reason)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, reason := a.Admit(types.Mention{AuthorID: "4"}); reason != "" {
+		t.Fatalf("Admit(4) = %q", reason)
+	}
+	if len(a.hits) != 1 {
+		t.Fatalf("hits = %v, want only the latest author", a.hits)
+	}
+}
@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"cg-mentions-bot/internal/types"
+)
+
+// StaleMode is how mentions older than Staleness.After are answered.
+type StaleMode string
+
+const (
+	// StaleStamp answers with current data and says when that data is from.
+	StaleStamp StaleMode = "stamp"
+	// StaleHistorical answers with the data as of the time the question was asked.
+	StaleHistorical StaleMode = "historical"
+	// StaleSkip does not answer stale mentions.
+	StaleSkip StaleMode = "skip"
+)
+
+// ReasonStale is reported for mentions skipped for being too old.
+const ReasonStale = "stale"
+
+// twitterEpoch is the snowflake epoch of tweet IDs, in unix milliseconds.
+const twitterEpoch = 1288834974657
+
+// ParseStaleMode parses a StaleMode; "" and "off" disable the policy.
+func ParseStaleMode(s string) (StaleMode, error) {
+	switch m := StaleMode(s); m {
+	case "", "off":
+		return "", nil
+	case StaleStamp, StaleHistorical, StaleSkip:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown stale mode %q (want stamp, historical, skip or off)", s)
+}
+
+// Staleness decides how delayed mentions are answered, based on how long ago
+// they were asked.
+type Staleness struct {
+	// Mode applies to mentions at least After old; empty answers them as if fresh.
+	Mode  StaleMode
+	After time.Duration
+	// MaxAge skips mentions older than it in any mode; zero disables the cutoff.
+	MaxAge time.Duration
+}
+
+// Verdict is the outcome of Staleness.Check. The zero Verdict means fresh.
+type Verdict struct {
+	Mode    StaleMode
+	AskedAt time.Time
+	Age     time.Duration
+	// Skip is ReasonStale when the mention should not be answered.
+	Skip string
+}
+
+// Check computes m's age at now and the mode that applies to it. Mentions of
+// unknown age are treated as fresh.
+func (s *Staleness) Check(m types.Mention, now time.Time) Verdict {
+	asked, ok := AskedAt(m)
+	if s == nil || !ok {
+		return Verdict{}
+	}
+	age := max(now.Sub(asked), 0)
+	v := Verdict{Mode: s.Mode, AskedAt: asked.UTC(), Age: age}
+	switch {
+	case s.MaxAge > 0 && age > s.MaxAge:
+		v.Skip = ReasonStale
+	case s.Mode == "" || age < s.After:
+		return Verdict{}
+	case s.Mode == StaleSkip:
+		v.Skip = ReasonStale
+	}
+	return v
+}
+
+// AskedAt returns when m was posted: its CreatedAt, or else the time encoded
+// in its snowflake tweet ID.
+func AskedAt(m types.Mention) (time.Time, bool) {
+	if !m.CreatedAt.IsZero() {
+		return m.CreatedAt, true
+	}
+	id, err := strconv.ParseInt(m.TweetID, 10, 64)
+	if err != nil || id>>22 == 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(id>>22 + twitterEpoch).UTC(), true
+}
+
+// FormatAge renders d coarsely for prompts, e.g. "45m", "6h" or "2d 3h".
+func FormatAge(d time.Duration) string {
+	d = d.Round(time.Minute)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		if m := int(d.Minutes()) % 60; m != 0 {
+			return fmt.Sprintf("%dh %dm", int(d.Hours()), m)
+		}
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	days := int(d.Hours()) / 24
+	if h := int(d.Hours()) % 24; h != 0 {
+		return fmt.Sprintf("%dd %dh", days, h)
+	}
+	return fmt.Sprintf("%dd", days)
+}
@@ -0,0 +1,73 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"cg-mentions-bot/internal/types"
+)
+
+func TestAskedAt(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 2, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		m    types.Mention
+		want time.Time
+		ok   bool
+	}{
+		{"created_at wins", types.Mention{TweetID: "1874244142494658617", CreatedAt: created}, created, true},
+		// Posted at 2025-01-01T00:00:00Z; the low bits are worker and sequence numbers.
+		{"snowflake", types.Mention{TweetID: "1874244142494658617"}, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"pre-snowflake id", types.Mention{TweetID: "20"}, time.Time{}, false},
+		{"not a number", types.Mention{TweetID: "abc"}, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := AskedAt(tt.m)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("%s: AskedAt = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestStalenessCheck(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	asked := func(ago time.Duration) types.Mention { return types.Mention{CreatedAt: now.Add(-ago)} }
+	tests := []struct {
+		name string
+		s    *Staleness
+		m    types.Mention
+		mode StaleMode
+		skip string
+	}{
+		{"nil policy", nil, asked(time.Hour), "", ""},
+		{"off", &Staleness{After: 10 * time.Minute}, asked(time.Hour), "", ""},
+		{"unknown age", &Staleness{Mode: StaleSkip}, types.Mention{TweetID: "x"}, "", ""},
+		{"fresh", &Staleness{Mode: StaleStamp, After: 10 * time.Minute}, asked(5 * time.Minute), "", ""},
+		{"stamp", &Staleness{Mode: StaleStamp, After: 10 * time.Minute}, asked(time.Hour), StaleStamp, ""},
+		{"historical", &Staleness{Mode: StaleHistorical, After: 10 * time.Minute}, asked(time.Hour), StaleHistorical, ""},
+		{"skip", &Staleness{Mode: StaleSkip, After: 10 * time.Minute}, asked(time.Hour), StaleSkip, ReasonStale},
+		{"max age while off", &Staleness{MaxAge: 30 * time.Minute}, asked(time.Hour), "", ReasonStale},
+		{"max age beats stamp", &Staleness{Mode: StaleStamp, After: 10 * time.Minute, MaxAge: 30 * time.Minute}, asked(time.Hour), StaleStamp, ReasonStale},
+		{"under max age", &Staleness{Mode: StaleStamp, After: 10 * time.Minute, MaxAge: 2 * time.Hour}, asked(time.Hour), StaleStamp, ""},
+	}
+	for _, tt := range tests {
+		v := tt.s.Check(tt.m, now)
+		if v.Mode != tt.mode || v.Skip != tt.skip {
+			t.Errorf("%s: Check = %+v, want mode %q skip %q", tt.name, v, tt.mode, tt.skip)
+		}
+		if (v.Mode != "" || v.Skip != "") && v.Age != now.Sub(tt.m.CreatedAt) {
+			t.Errorf("%s: age = %v, want %v", tt.name, v.Age, now.Sub(tt.m.CreatedAt))
+		}
+	}
+}
+
+func TestParseStaleMode(t *testing.T) {
+	for in, want := range map[string]StaleMode{"": "", "off": "", "stamp": StaleStamp, "historical": StaleHistorical, "skip": StaleSkip} {
+		if got, err := ParseStaleMode(in); err != nil || got != want {
+			t.Errorf("ParseStaleMode(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseStaleMode("later"); err == nil {
+		t.Error("ParseStaleMode(later) succeeded")
+	}
+}
@@ -0,0 +1,87 @@
+package poller
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cg-mentions-bot/internal/metrics"
+	"cg-mentions-bot/internal/types"
+)
+
+// Poller periodically fetches new mentions of UserID and submits them for processing.
+type Poller struct {
+	UserID   string
+	Interval time.Duration
+	// Fetch lists mentions newer than sinceID and returns the new cursor.
+	Fetch func(ctx context.Context, userID, sinceID string) ([]types.Mention, string, error)
+	// Submit hands mentions to the processing pipeline.
+	Submit func(ctx context.Context, mentions []types.Mention) (int, error)
+	// Cursor persists since_id between runs.
+	Cursor Cursor
+}
+
+// Cursor loads and saves the since_id cursor.
+type Cursor interface {
+	GetState(key string) (string, error)
+	PutState(key, value string) error
+}
+
+func (p *Poller) cursorKey() string { return "poller.since_id." + p.UserID }
+
+// Run polls immediately and then every Interval until ctx is cancelled.
+func (p *Poller) Run(ctx context.Context) {
+	t := time.NewTicker(p.Interval)
+	defer t.Stop()
+	for {
+		if n, err := p.Poll(ctx); err != nil {
+			log.Printf("poller: %v", err)
+		} else if n > 0 {
+			log.Printf("poller: submitted %d mentions", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Poll performs one fetch and submit cycle. The cursor only advances once every
+// fetched mention was accepted, so nothing is lost if the queue is full.
+//
+// Without a saved cursor (the first start), Poll only records the newest
+// mention so the backlog of old mentions is not answered.
+func (p *Poller) Poll(ctx context.Context) (int, error) {
+	since, err := p.Cursor.GetState(p.cursorKey())
+	if err != nil {
+		return 0, err
+	}
+	mentions, newest, err := p.Fetch(ctx, p.UserID, since)
+	if err != nil {
+		return 0, err
+	}
+	if since == "" {
+		if newest == "" {
+			return 0, nil
+		}
+		if err := p.Cursor.PutState(p.cursorKey(), newest); err != nil {
+			return 0, err
+		}
+		log.Printf("poller: first start, skipping %d earlier mentions and answering those after %s", len(mentions), newest)
+		return 0, nil
+	}
+	n := 0
+	if len(mentions) > 0 {
+		metrics.MentionsReceived.WithLabelValues("poller").Add(float64(len(mentions)))
+		if n, err = p.Submit(ctx, mentions); err != nil {
+			return n, err
+		}
+	}
+	if newest != "" && newest != since {
+		if err := p.Cursor.PutState(p.cursorKey(), newest); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,185 @@
+package poller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"cg-mentions-bot/internal/twitter"
+	"cg-mentions-bot/internal/types"
+)
+
+// memCursor is an in-memory Cursor.
+type memCursor map[string]string
+
+func (c memCursor) GetState(key string) (string, error) { return c[key], nil }
+func (c memCursor) PutState(key, value string) error    { c[key] = value; return nil }
+
+// fakeX serves GET /users/:id/mentions from pages keyed by pagination_token
+// ("" is the first page) and records the query of every request.
+type fakeX struct {
+	mu       sync.Mutex
+	pages    map[string]map[string]any
+	limited  int // respond 429 to this many requests first
+	requests []map[string]string
+}
+
+func (f *fakeX) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := map[string]string{}
+	for k := range r.URL.Query() {
+		q[k] = r.URL.Query().Get(k)
+	}
+	f.requests = append(f.requests, q)
+	if r.URL.Path != "/users/42/mentions" {
+		http.NotFound(w, r)
+		return
+	}
+	if f.limited > 0 {
+		f.limited--
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, `{"title":"Too Many Requests"}`, http.StatusTooManyRequests)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(f.pages[q["pagination_token"]])
+}
+
+func page(newest, next string, ids ...string) map[string]any {
+	var data []map[string]any
+	for _, id := range ids {
+		data = append(data, map[string]any{"id": id, "text": "@bot price of btc? " + id, "author_id": "7"})
+	}
+	return map[string]any{
+		"data":     data,
+		"includes": map[string]any{"users": []map[string]any{{"id": "7", "username": "alice"}}},
+		"meta":     map[string]any{"newest_id": newest, "next_token": next},
+	}
+}
+
+func newPoller(t *testing.T, x *fakeX, cursor memCursor) (*Poller, *[]types.Mention) {
+	t.Helper()
+	srv := httptest.NewServer(x)
+	t.Cleanup(srv.Close)
+	var got []types.Mention
+	return &Poller{
+		UserID: "42",
+		Fetch:  twitter.NewMentionsFetcher(srv.URL, "token"),
+		Submit: func(_ context.Context, ms []types.Mention) (int, error) {
+			got = append(got, ms...)
+			return len(ms), nil
+		},
+		Cursor: cursor,
+	}, &got
+}
+
+func TestFirstPollSeedsCursorWithoutAnswering(t *testing.T) {
+	x := &fakeX{pages: map[string]map[string]any{
+		"":   page("30", "p2", "30", "20"),
+		"p2": page("", "", "10"),
+	}}
+	cursor := memCursor{}
+	p, got := newPoller(t, x, cursor)
+
+	n, err := p.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 || len(*got) != 0 {
+		t.Fatalf("first poll submitted %d mentions, want none", len(*got))
+	}
+	if c := cursor[p.cursorKey()]; c != "30" {
+		t.Fatalf("cursor = %q, want 30", c)
+	}
+	if len(x.requests) != 1 {
+		t.Fatalf("first poll made %d requests, want 1 (no pagination)", len(x.requests))
+	}
+}
+
+func TestPollSendsSinceIDAndWalksPages(t *testing.T) {
+	x := &fakeX{pages: map[string]map[string]any{
+		"":   page("50", "p2", "50", "40"),
+		"p2": page("", "", "35"),
+	}}
+	cursor := memCursor{"poller.since_id.42": "30"}
+	p, got := newPoller(t, x, cursor)
+
+	n, err := p.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("submitted %d, want 3", n)
+	}
+	var ids []string
+	for _, m := range *got {
+		ids = append(ids, m.TweetID)
+		if m.AuthorUsername != "alice" {
+			t.Errorf("mention %s: author_username = %q, want alice", m.TweetID, m.AuthorUsername)
+		}
+	}
+	if want := []string{"35", "40", "50"}; !slices.Equal(ids, want) {
+		t.Fatalf("submitted %v, want oldest first %v", ids, want)
+	}
+	for i, q := range x.requests {
+		if q["since_id"] != "30" {
+			t.Errorf("request %d: since_id = %q, want 30", i, q["since_id"])
+		}
+	}
+	if x.requests[1]["pagination_token"] != "p2" {
+		t.Errorf("second request pagination_token = %q, want p2", x.requests[1]["pagination_token"])
+	}
+	if c := cursor[p.cursorKey()]; c != "50" {
+		t.Fatalf("cursor = %q, want 50", c)
+	}
+}
+
+func TestPollKeepsCursorWhenSubmitFails(t *testing.T) {
+	x := &fakeX{pages: map[string]map[string]any{"": page("50", "", "50")}}
+	cursor := memCursor{"poller.since_id.42": "30"}
+	p, _ := newPoller(t, x, cursor)
+	p.Submit = func(context.Context, []types.Mention) (int, error) {
+		return 0, errors.New("job queue is full")
+	}
+
+	if _, err := p.Poll(context.Background()); err == nil {
+		t.Fatal("Poll succeeded, want the submit error")
+	}
+	if c := cursor[p.cursorKey()]; c != "30" {
+		t.Fatalf("cursor = %q, want it left at 30", c)
+	}
+}
+
+func TestPollBacksOffOnRateLimit(t *testing.T) {
+	x := &fakeX{limited: 2, pages: map[string]map[string]any{"": page("50", "", "50")}}
+	cursor := memCursor{"poller.since_id.42": "30"}
+	p, got := newPoller(t, x, cursor)
+
+	if _, err := p.Poll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(x.requests) != 3 {
+		t.Fatalf("made %d requests, want 2 rate-limited retries and 1 success", len(x.requests))
+	}
+	if len(*got) != 1 || cursor[p.cursorKey()] != "50" {
+		t.Fatalf("got %d mentions and cursor %q after the rate limit cleared", len(*got), cursor[p.cursorKey()])
+	}
+}
+
+func TestPollRateLimitedKeepsCursor(t *testing.T) {
+	x := &fakeX{limited: 100}
+	cursor := memCursor{"poller.since_id.42": "30"}
+	p, got := newPoller(t, x, cursor)
+
+	if _, err := p.Poll(context.Background()); err == nil {
+		t.Fatal("Poll succeeded while rate limited")
+	}
+	if len(*got) != 0 || cursor[p.cursorKey()] != "30" {
+		t.Fatalf("got %d mentions and cursor %q, want none and 30", len(*got), cursor[p.cursorKey()])
+	}
+}
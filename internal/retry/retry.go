@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"cg-mentions-bot/internal/policy"
+	"cg-mentions-bot/internal/store"
+	"cg-mentions-bot/internal/types"
+)
+
+// Retrier replays dead-lettered mentions with exponential backoff while they
+// are fresh enough to be worth answering.
+type Retrier struct {
+	// Interval is how often dead letters are checked.
+	Interval time.Duration
+	// Backoff is the delay after the first failure; it doubles with every
+	// further attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts includes the first, failed attempt.
+	MaxAttempts int
+	// Window is how old a mention may get before it is no longer retried.
+	Window time.Duration
+	// Submit hands mentions to the processing pipeline.
+	Submit func(ctx context.Context, mentions []types.Mention) (int, error)
+	Store  DeadLetters
+}
+
+// DeadLetters lists and updates dead-lettered mentions.
+type DeadLetters interface {
+	ListDeadLetters(status store.DeadLetterStatus) ([]store.DeadLetter, error)
+	UpdateDeadLetter(tweetID string, fn func(d *store.DeadLetter) error) (store.DeadLetter, error)
+}
+
+// Run sweeps every Interval until ctx is cancelled.
+func (r *Retrier) Run(ctx context.Context) {
+	t := time.NewTicker(r.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if n, err := r.Sweep(ctx, time.Now()); err != nil {
+			log.Printf("retry: %v", err)
+		} else if n > 0 {
+			log.Printf("retry: resubmitted %d mentions", n)
+		}
+	}
+}
+
+// Delay returns how long to wait after the given number of failed attempts.
+func (r *Retrier) Delay(attempts int) time.Duration {
+	d := r.Backoff
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if r.MaxBackoff > 0 && d >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return d
+}
+
+// Sweep resubmits the dead letters that are due at now and marks those out of
+// attempts or freshness as exhausted. It returns how many were resubmitted.
+func (r *Retrier) Sweep(ctx context.Context, now time.Time) (int, error) {
+	letters, err := r.Store.ListDeadLetters(store.DeadLetterRetrying)
+	if err != nil {
+		return 0, err
+	}
+	var due []types.Mention
+	for _, d := range letters {
+		if r.exhausted(d, now) {
+			if _, err := r.Store.UpdateDeadLetter(d.TweetID, func(d *store.DeadLetter) error {
+				d.Status = store.DeadLetterExhausted
+				return nil
+			}); err != nil {
+				return 0, err
+			}
+			log.Printf("retry: giving up on %s after %d attempts: %s", d.TweetID, d.Attempts, d.Error)
+			continue
+		}
+		if now.Sub(d.LastAttemptAt) >= r.Delay(d.Attempts) {
+			due = append(due, d.Mention)
+		}
+	}
+	if len(due) == 0 {
+		return 0, nil
+	}
+
+	n, err := r.Submit(ctx, due)
+	// Restart the backoff clock for what was accepted, so a mention is not
+	// resubmitted while its retry is still queued.
+	for _, m := range due[:n] {
+		if _, uerr := r.Store.UpdateDeadLetter(m.TweetID, func(d *store.DeadLetter) error {
+			d.LastAttemptAt = now.UTC()
+			return nil
+		}); uerr != nil && !errors.Is(uerr, store.ErrNotFound) && err == nil {
+			err = uerr
+		}
+	}
+	return n, err
+}
+
+func (r *Retrier) exhausted(d store.DeadLetter, now time.Time) bool {
+	if r.MaxAttempts > 0 && d.Attempts >= r.MaxAttempts {
+		return true
+	}
+	asked, ok := policy.AskedAt(d.Mention)
+	if !ok {
+		asked = d.FirstFailedAt
+	}
+	return r.Window > 0 && now.Sub(asked) > r.Window
+}
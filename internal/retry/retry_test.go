@@ -0,0 +1,131 @@
+package retry
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cg-mentions-bot/internal/store"
+	"cg-mentions-bot/internal/types"
+)
+
+func TestDelay(t *testing.T) {
+	r := &Retrier{Backoff: time.Minute, MaxBackoff: 5 * time.Minute}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 5 * time.Minute},
+		{30, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := r.Delay(tt.attempts); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+	if got := (&Retrier{Backoff: time.Minute}).Delay(4); got != 8*time.Minute {
+		t.Errorf("Delay(4) without a cap = %v, want 8m", got)
+	}
+}
+
+func TestExhausted(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := &Retrier{MaxAttempts: 3, Window: time.Hour}
+	tests := []struct {
+		name string
+		d    store.DeadLetter
+		want bool
+	}{
+		{"fresh", store.DeadLetter{Attempts: 1, Mention: types.Mention{CreatedAt: now.Add(-time.Minute)}}, false},
+		{"out of attempts", store.DeadLetter{Attempts: 3, Mention: types.Mention{CreatedAt: now.Add(-time.Minute)}}, true},
+		{"asked too long ago", store.DeadLetter{Attempts: 1, Mention: types.Mention{CreatedAt: now.Add(-2 * time.Hour)}}, true},
+		{"unknown age, failed recently", store.DeadLetter{Attempts: 1, FirstFailedAt: now.Add(-time.Minute)}, false},
+		{"unknown age, failed long ago", store.DeadLetter{Attempts: 1, FirstFailedAt: now.Add(-2 * time.Hour)}, true},
+	}
+	for _, tt := range tests {
+		if got := r.exhausted(tt.d, now); got != tt.want {
+			t.Errorf("%s: exhausted = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if (&Retrier{}).exhausted(store.DeadLetter{Attempts: 50, FirstFailedAt: now.AddDate(-1, 0, 0)}, now) {
+		t.Error("exhausted without limits = true, want false")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	st, err := store.Open(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	now := time.Now().UTC()
+	fail := func(id string, attempts int, created, last time.Time, status store.DeadLetterStatus) {
+		t.Helper()
+		if _, err := st.FailDeadLetter(types.Mention{TweetID: id, CreatedAt: created}, "boom"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := st.UpdateDeadLetter(id, func(d *store.DeadLetter) error {
+			d.Attempts, d.LastAttemptAt, d.Status = attempts, last, status
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fail("due", 1, now.Add(-5*time.Minute), now.Add(-2*time.Minute), store.DeadLetterRetrying)
+	fail("waiting", 2, now.Add(-5*time.Minute), now.Add(-90*time.Second), store.DeadLetterRetrying)
+	fail("spent", 3, now.Add(-5*time.Minute), now.Add(-time.Hour), store.DeadLetterRetrying)
+	fail("old", 1, now.Add(-2*time.Hour), now.Add(-time.Hour), store.DeadLetterRetrying)
+	fail("dead", 1, now.Add(-5*time.Minute), now.Add(-time.Hour), store.DeadLetterExhausted)
+
+	var submitted []string
+	r := &Retrier{
+		Backoff:     time.Minute,
+		MaxAttempts: 3,
+		Window:      time.Hour,
+		Store:       st,
+		Submit: func(_ context.Context, mentions []types.Mention) (int, error) {
+			for _, m := range mentions {
+				submitted = append(submitted, m.TweetID)
+			}
+			return len(mentions), nil
+		},
+	}
+	n, err := r.Sweep(context.Background(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 || len(submitted) != 1 || submitted[0] != "due" {
+		t.Fatalf("Sweep resubmitted %d: %v, want only due", n, submitted)
+	}
+
+	for id, want := range map[string]store.DeadLetterStatus{
+		"due":     store.DeadLetterRetrying,
+		"waiting": store.DeadLetterRetrying,
+		"spent":   store.DeadLetterExhausted,
+		"old":     store.DeadLetterExhausted,
+		"dead":    store.DeadLetterExhausted,
+	} {
+		d, err := st.GetDeadLetter(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d.Status != want {
+			t.Errorf("%s: status = %s, want %s", id, d.Status, want)
+		}
+		if id == "due" && !d.LastAttemptAt.Equal(now) {
+			t.Errorf("due: last attempt = %v, want the sweep time", d.LastAttemptAt)
+		}
+	}
+
+	// A second sweep finds nothing due: the resubmitted mention waits for its backoff.
+	submitted = nil
+	if n, err := r.Sweep(context.Background(), now.Add(10*time.Second)); err != nil || n != 0 {
+		t.Fatalf("second Sweep = %d, %v; submitted %v", n, err, submitted)
+	}
+}
@@ -0,0 +1,173 @@
+package store
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"cg-mentions-bot/internal/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketAudit = []byte("audit")
+
+// maxAuditAttempts bounds the attempts kept per mention; older ones are dropped.
+const maxAuditAttempts = 20
+
+// AuditEntry is the history of one mention: what it said and every attempt to
+// answer it.
+type AuditEntry struct {
+	TweetID        string        `json:"tweet_id"`
+	AuthorUsername string        `json:"author_username,omitempty"`
+	Mention        types.Mention `json:"mention"`
+	// NormalizedText is the tweet text without handles and URLs.
+	NormalizedText string         `json:"normalized_text"`
+	Attempts       []AuditAttempt `json:"attempts"`
+	FirstSeenAt    time.Time      `json:"first_seen_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// AuditAttempt is one run of the pipeline (or a manual re-post) for a mention.
+type AuditAttempt struct {
+	At time.Time `json:"at"`
+	// Action is "process" for pipeline runs, "repost" for manual re-posts and
+	// "approve" or "reject" for reviewed drafts.
+	Action string `json:"action"`
+	// Outcome is posted, drafted, skipped or failed; Reason holds the skip
+	// reason or the failed stage.
+	Outcome  string   `json:"outcome"`
+	Reason   string   `json:"reason,omitempty"`
+	Category string   `json:"category,omitempty"`
+	Lang     string   `json:"lang,omitempty"`
+	Question string   `json:"question,omitempty"`
+	Answer   string   `json:"answer,omitempty"`
+	Tools    []string `json:"tools,omitempty"`
+	Posted   bool     `json:"posted"`
+	ReplyID  string   `json:"reply_id,omitempty"`
+	Error    string   `json:"error,omitempty"`
+	TraceID  string   `json:"trace_id,omitempty"`
+	// DurationMS is how long the attempt took end to end.
+	DurationMS int64 `json:"duration_ms"`
+}
+
+// Last returns the latest attempt.
+func (e AuditEntry) Last() AuditAttempt {
+	if len(e.Attempts) == 0 {
+		return AuditAttempt{}
+	}
+	return e.Attempts[len(e.Attempts)-1]
+}
+
+// AuditFilter selects entries in ListAudit. Zero fields match everything.
+type AuditFilter struct {
+	// Outcome matches the latest attempt's outcome.
+	Outcome  string
+	Author   string
+	Category string
+	// Text matches a case-insensitive substring of the normalized text.
+	Text  string
+	Since time.Time
+	Limit int
+}
+
+// AppendAudit records attempt a for mention m, creating its entry on first use.
+func (s *Store) AppendAudit(m types.Mention, normalized string, a AuditAttempt) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAudit)
+		var e AuditEntry
+		found, err := getJSON(b, m.TweetID, &e)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if !found {
+			e = AuditEntry{TweetID: m.TweetID, FirstSeenAt: now}
+		}
+		e.AuthorUsername = m.AuthorUsername
+		e.Mention = m
+		e.NormalizedText = normalized
+		if a.At.IsZero() {
+			a.At = now
+		}
+		e.Attempts = append(e.Attempts, a)
+		if len(e.Attempts) > maxAuditAttempts {
+			e.Attempts = e.Attempts[len(e.Attempts)-maxAuditAttempts:]
+		}
+		e.UpdatedAt = now
+		return putJSON(b, m.TweetID, e)
+	})
+}
+
+// GetAudit returns the history of tweetID.
+func (s *Store) GetAudit(tweetID string) (AuditEntry, error) {
+	var e AuditEntry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		found, err := getJSON(tx.Bucket(bucketAudit), tweetID, &e)
+		if err == nil && !found {
+			err = ErrNotFound
+		}
+		return err
+	})
+	return e, err
+}
+
+// ListAudit returns the entries matching f, most recently updated first.
+func (s *Store) ListAudit(f AuditFilter) ([]AuditEntry, error) {
+	out := make([]AuditEntry, 0)
+	text := strings.ToLower(f.Text)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAudit).ForEach(func(_, v []byte) error {
+			var e AuditEntry
+			if err := decodeJSON(v, &e); err != nil {
+				return err
+			}
+			last := e.Last()
+			switch {
+			case f.Outcome != "" && last.Outcome != f.Outcome,
+				f.Author != "" && !strings.EqualFold(e.AuthorUsername, strings.TrimPrefix(f.Author, "@")),
+				f.Category != "" && last.Category != f.Category,
+				text != "" && !strings.Contains(strings.ToLower(e.NormalizedText), text),
+				!f.Since.IsZero() && e.UpdatedAt.Before(f.Since):
+				return nil
+			}
+			out = append(out, e)
+			return nil
+		})
+	})
+	sort.Slice(out, func(a, b int) bool { return out[a].UpdatedAt.After(out[b].UpdatedAt) })
+	if f.Limit > 0 && len(out) > f.Limit {
+		out = out[:f.Limit]
+	}
+	return out, err
+}
+
+// PruneAudit deletes entries last updated before cutoff and returns how many.
+func (s *Store) PruneAudit(cutoff time.Time) (int, error) {
+	n := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketAudit)
+		var stale [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var e AuditEntry
+			if err := decodeJSON(v, &e); err != nil {
+				return err
+			}
+			if e.UpdatedAt.Before(cutoff) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		n = len(stale)
+		return nil
+	})
+	return n, err
+}
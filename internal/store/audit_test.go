@@ -0,0 +1,97 @@
+package store
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"cg-mentions-bot/internal/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// putAudit stores e as is, so tests control UpdatedAt.
+func putAudit(t *testing.T, s *Store, e AuditEntry) {
+	t.Helper()
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(bucketAudit), e.TweetID, e)
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func auditIDs(entries []AuditEntry) string {
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.TweetID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestListAuditFilters(t *testing.T) {
+	s := openTest(t)
+	now := time.Now().UTC()
+	putAudit(t, s, AuditEntry{TweetID: "1", AuthorUsername: "Alice", NormalizedText: "btc price?", UpdatedAt: now.Add(-3 * time.Hour),
+		Attempts: []AuditAttempt{{Outcome: "failed", Category: "crypto_question"}, {Outcome: "posted", Category: "crypto_question"}}})
+	putAudit(t, s, AuditEntry{TweetID: "2", AuthorUsername: "bob", NormalizedText: "gm", UpdatedAt: now.Add(-2 * time.Hour),
+		Attempts: []AuditAttempt{{Outcome: "skipped", Reason: "ignored", Category: "greeting"}}})
+	putAudit(t, s, AuditEntry{TweetID: "3", AuthorUsername: "alice", NormalizedText: "ETH market cap", UpdatedAt: now.Add(-time.Hour),
+		Attempts: []AuditAttempt{{Outcome: "failed", Reason: "agent", Category: "crypto_question"}}})
+
+	tests := []struct {
+		name string
+		f    AuditFilter
+		want string
+	}{
+		{"all, newest first", AuditFilter{}, "3,2,1"},
+		{"author ignores case and @", AuditFilter{Author: "@ALICE"}, "3,1"},
+		{"status is the latest outcome", AuditFilter{Outcome: "failed"}, "3"},
+		{"posted after a failure", AuditFilter{Outcome: "posted"}, "1"},
+		{"category", AuditFilter{Category: "greeting"}, "2"},
+		{"text", AuditFilter{Text: "Market"}, "3"},
+		{"since", AuditFilter{Since: now.Add(-150 * time.Minute)}, "3,2"},
+		{"combined", AuditFilter{Author: "alice", Since: now.Add(-4 * time.Hour), Outcome: "posted"}, "1"},
+		{"limit", AuditFilter{Limit: 2}, "3,2"},
+		{"no match", AuditFilter{Author: "carol"}, ""},
+	}
+	for _, tt := range tests {
+		got, err := s.ListAudit(tt.f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := auditIDs(got); ids != tt.want {
+			t.Errorf("%s: ListAudit = %q, want %q", tt.name, ids, tt.want)
+		}
+	}
+}
+
+func TestPruneAudit(t *testing.T) {
+	s := openTest(t)
+	now := time.Now().UTC()
+	putAudit(t, s, AuditEntry{TweetID: "old", UpdatedAt: now.Add(-31 * 24 * time.Hour)})
+	putAudit(t, s, AuditEntry{TweetID: "recent", UpdatedAt: now.Add(-29 * 24 * time.Hour)})
+	// A new attempt on an old mention keeps it.
+	putAudit(t, s, AuditEntry{TweetID: "revived", FirstSeenAt: now.Add(-60 * 24 * time.Hour), UpdatedAt: now.Add(-60 * 24 * time.Hour)})
+	if err := s.AppendAudit(types.Mention{TweetID: "revived"}, "", AuditAttempt{Outcome: "posted"}); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.PruneAudit(now.Add(-30 * 24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("PruneAudit removed %d entries, want 1", n)
+	}
+	got, err := s.ListAudit(AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := auditIDs(got); ids != "revived,recent" {
+		t.Errorf("after pruning = %q, want revived,recent", ids)
+	}
+	if _, err := s.GetAudit("old"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetAudit(old) = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"cg-mentions-bot/internal/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketDeadLetters = []byte("dead_letters")
+
+// DeadLetterStatus tells whether a failed mention is still retried automatically.
+type DeadLetterStatus string
+
+const (
+	// DeadLetterRetrying mentions are replayed with backoff while they are fresh.
+	DeadLetterRetrying DeadLetterStatus = "retrying"
+	// DeadLetterExhausted mentions ran out of attempts or freshness and are only
+	// replayed manually.
+	DeadLetterExhausted DeadLetterStatus = "exhausted"
+)
+
+// DeadLetter is a mention whose answer or reply failed, kept for replays.
+type DeadLetter struct {
+	TweetID       string           `json:"tweet_id"`
+	Mention       types.Mention    `json:"mention"`
+	Error         string           `json:"error"`
+	Attempts      int              `json:"attempts"`
+	Status        DeadLetterStatus `json:"status"`
+	FirstFailedAt time.Time        `json:"first_failed_at"`
+	LastAttemptAt time.Time        `json:"last_attempt_at"`
+}
+
+// FailDeadLetter records a failed attempt for m, creating the dead letter on
+// the first failure. Exhausted dead letters stay exhausted.
+func (s *Store) FailDeadLetter(m types.Mention, errMsg string) (DeadLetter, error) {
+	var d DeadLetter
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeadLetters)
+		found, err := getJSON(b, m.TweetID, &d)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if !found {
+			d = DeadLetter{TweetID: m.TweetID, Status: DeadLetterRetrying, FirstFailedAt: now}
+		}
+		d.Mention = m
+		d.Error = errMsg
+		d.Attempts++
+		d.LastAttemptAt = now
+		return putJSON(b, m.TweetID, d)
+	})
+	return d, err
+}
+
+// GetDeadLetter returns the dead letter for tweetID.
+func (s *Store) GetDeadLetter(tweetID string) (DeadLetter, error) {
+	var d DeadLetter
+	err := s.db.View(func(tx *bolt.Tx) error {
+		found, err := getJSON(tx.Bucket(bucketDeadLetters), tweetID, &d)
+		if err == nil && !found {
+			err = ErrNotFound
+		}
+		return err
+	})
+	return d, err
+}
+
+// UpdateDeadLetter applies fn to the dead letter for tweetID in one transaction.
+// If fn returns an error nothing is written.
+func (s *Store) UpdateDeadLetter(tweetID string, fn func(d *DeadLetter) error) (DeadLetter, error) {
+	var d DeadLetter
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDeadLetters)
+		found, err := getJSON(b, tweetID, &d)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrNotFound
+		}
+		if err := fn(&d); err != nil {
+			return err
+		}
+		return putJSON(b, tweetID, d)
+	})
+	return d, err
+}
+
+// DeleteDeadLetter forgets tweetID once it no longer fails. Missing entries are ignored.
+func (s *Store) DeleteDeadLetter(tweetID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketDeadLetters).Delete([]byte(tweetID))
+	})
+}
+
+// ListDeadLetters returns dead letters with the given status (all when empty),
+// oldest failure first.
+func (s *Store) ListDeadLetters(status DeadLetterStatus) ([]DeadLetter, error) {
+	out := make([]DeadLetter, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketDeadLetters).ForEach(func(_, v []byte) error {
+			var d DeadLetter
+			if err := decodeJSON(v, &d); err != nil {
+				return err
+			}
+			if status == "" || d.Status == status {
+				out = append(out, d)
+			}
+			return nil
+		})
+	})
+	sort.Slice(out, func(a, b int) bool { return out[a].FirstFailedAt.Before(out[b].FirstFailedAt) })
+	return out, err
+}
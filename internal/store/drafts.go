@@ -0,0 +1,106 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketDrafts = []byte("drafts")
+
+// DraftStatus is the review state of a proposed reply.
+type DraftStatus string
+
+const (
+	DraftPending  DraftStatus = "pending"
+	DraftPosting  DraftStatus = "posting"
+	DraftPosted   DraftStatus = "posted"
+	DraftRejected DraftStatus = "rejected"
+)
+
+// ErrNotFound is returned when a keyed item does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Draft is a reply waiting for human approval, keyed by the tweet it answers.
+type Draft struct {
+	TweetID        string      `json:"tweet_id"`
+	ConversationID string      `json:"conversation_id,omitempty"`
+	AuthorUsername string      `json:"author_username,omitempty"`
+	Question       string      `json:"question"`
+	Text           string      `json:"text"`
+	Category       string      `json:"category,omitempty"`
+	Lang           string      `json:"lang,omitempty"`
+	Tools          []string    `json:"tools,omitempty"`
+	Status         DraftStatus `json:"status"`
+	Error          string      `json:"error,omitempty"`
+	// ReplyID is the tweet ID of the reply once the draft is posted.
+	ReplyID   string    `json:"reply_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PutDraft stores a new pending draft, replacing any earlier one for the tweet.
+func (s *Store) PutDraft(d Draft) error {
+	now := time.Now().UTC()
+	d.Status = DraftPending
+	d.CreatedAt, d.UpdatedAt = now, now
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(bucketDrafts), d.TweetID, d)
+	})
+}
+
+// GetDraft returns the draft for tweetID.
+func (s *Store) GetDraft(tweetID string) (Draft, error) {
+	var d Draft
+	err := s.db.View(func(tx *bolt.Tx) error {
+		found, err := getJSON(tx.Bucket(bucketDrafts), tweetID, &d)
+		if err == nil && !found {
+			err = ErrNotFound
+		}
+		return err
+	})
+	return d, err
+}
+
+// UpdateDraft applies fn to the draft for tweetID in one transaction. If fn
+// returns an error nothing is written.
+func (s *Store) UpdateDraft(tweetID string, fn func(d *Draft) error) (Draft, error) {
+	var d Draft
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketDrafts)
+		found, err := getJSON(b, tweetID, &d)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrNotFound
+		}
+		if err := fn(&d); err != nil {
+			return err
+		}
+		d.UpdatedAt = time.Now().UTC()
+		return putJSON(b, tweetID, d)
+	})
+	return d, err
+}
+
+// ListDrafts returns drafts with the given status (all when empty), oldest first.
+func (s *Store) ListDrafts(status DraftStatus) ([]Draft, error) {
+	out := make([]Draft, 0)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketDrafts).ForEach(func(_, v []byte) error {
+			var d Draft
+			if err := decodeJSON(v, &d); err != nil {
+				return err
+			}
+			if status == "" || d.Status == status {
+				out = append(out, d)
+			}
+			return nil
+		})
+	})
+	sort.Slice(out, func(a, b int) bool { return out[a].CreatedAt.Before(out[b].CreatedAt) })
+	return out, err
+}
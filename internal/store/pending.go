@@ -0,0 +1,48 @@
+package store
+
+import (
+	"cg-mentions-bot/internal/types"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketPending = []byte("pending")
+
+// SavePending keeps mentions that were accepted but not answered before a
+// shutdown, so the next start can queue them again.
+func (s *Store) SavePending(mentions []types.Mention) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketPending)
+		for _, m := range mentions {
+			if err := putJSON(b, m.TweetID, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TakePending returns and removes the mentions saved by SavePending.
+func (s *Store) TakePending() ([]types.Mention, error) {
+	var out []types.Mention
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketPending)
+		if err := b.ForEach(func(_, v []byte) error {
+			var m types.Mention
+			if err := decodeJSON(v, &m); err != nil {
+				return err
+			}
+			out = append(out, m)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, m := range out {
+			if err := b.Delete([]byte(m.TweetID)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return out, err
+}
@@ -0,0 +1,137 @@
+package store
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	bucketProcessed     = []byte("processed")
+	bucketConversations = []byte("conversations")
+)
+
+// Status is the processing state recorded for a tweet.
+type Status string
+
+const (
+	StatusProcessing Status = "processing"
+	StatusPosted     Status = "posted"
+	StatusFailed     Status = "failed"
+	StatusSkipped    Status = "skipped"
+	// StatusDrafted means a reply is waiting in the approval queue.
+	StatusDrafted Status = "drafted"
+)
+
+// claimTTL is how long a "processing" claim blocks other attempts. It covers
+// crashes that leave a claim behind without a final status.
+const claimTTL = 30 * time.Minute
+
+// Record is what we remember about a mention we have handled.
+type Record struct {
+	TweetID        string `json:"tweet_id"`
+	ConversationID string `json:"conversation_id,omitempty"`
+	AuthorUsername string `json:"author_username,omitempty"`
+	// Question is the normalized tweet text, without any added context.
+	Question string `json:"question,omitempty"`
+	Answer   string `json:"answer,omitempty"`
+	// ReplyID is the tweet ID of the posted reply, when known.
+	ReplyID   string    `json:"reply_id,omitempty"`
+	Status    Status    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Claim marks tweetID as being processed. It returns false when the tweet was
+// already answered or deliberately skipped, or another worker holds a fresh
+// claim on it. Failed tweets may be claimed again.
+func (s *Store) Claim(tweetID string) (bool, error) {
+	claimed := false
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketProcessed)
+		var rec Record
+		found, err := getJSON(b, tweetID, &rec)
+		if err != nil {
+			return err
+		}
+		if found {
+			switch rec.Status {
+			case StatusPosted, StatusSkipped, StatusDrafted:
+				return nil
+			case StatusProcessing:
+				if time.Since(rec.UpdatedAt) < claimTTL {
+					return nil
+				}
+			}
+		}
+		claimed = true
+		return putJSON(b, tweetID, Record{TweetID: tweetID, Status: StatusProcessing, UpdatedAt: time.Now().UTC()})
+	})
+	return claimed, err
+}
+
+// Release drops a claim without recording an outcome, so the tweet can be
+// claimed again later.
+func (s *Store) Release(tweetID string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketProcessed).Delete([]byte(tweetID))
+	})
+}
+
+// Finish records the final outcome for a claimed tweet.
+func (s *Store) Finish(rec Record) error {
+	rec.UpdatedAt = time.Now().UTC()
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if rec.ConversationID != "" {
+			if err := tx.Bucket(bucketConversations).Put(conversationKey(rec.ConversationID, rec.TweetID), nil); err != nil {
+				return err
+			}
+		}
+		return putJSON(tx.Bucket(bucketProcessed), rec.TweetID, rec)
+	})
+}
+
+// Conversation returns the answered records in conversationID, oldest first,
+// keeping at most the last limit entries.
+func (s *Store) Conversation(conversationID string, limit int) ([]Record, error) {
+	var out []Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		processed := tx.Bucket(bucketProcessed)
+		prefix := conversationKey(conversationID, "")
+		c := tx.Bucket(bucketConversations).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			var rec Record
+			found, err := getJSON(processed, string(k[len(prefix):]), &rec)
+			if err != nil {
+				return err
+			}
+			if found && rec.Status == StatusPosted && rec.Answer != "" {
+				out = append(out, rec)
+			}
+		}
+		return nil
+	})
+	sort.Slice(out, func(a, b int) bool { return out[a].UpdatedAt.Before(out[b].UpdatedAt) })
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, err
+}
+
+func conversationKey(conversationID, tweetID string) []byte {
+	return []byte(conversationID + "\x00" + tweetID)
+}
+
+// Get returns the record for tweetID, if any.
+func (s *Store) Get(tweetID string) (Record, bool, error) {
+	var rec Record
+	var found bool
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		found, err = getJSON(tx.Bucket(bucketProcessed), tweetID, &rec)
+		return err
+	})
+	return rec, found, err
+}
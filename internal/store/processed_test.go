@@ -0,0 +1,100 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func openTest(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "bot.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func claim(t *testing.T, s *Store, id string) bool {
+	t.Helper()
+	ok, err := s.Claim(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ok
+}
+
+func TestClaim(t *testing.T) {
+	s := openTest(t)
+	if !claim(t, s, "1") {
+		t.Fatal("first Claim was refused")
+	}
+	if claim(t, s, "1") {
+		t.Fatal("Claim succeeded while another worker holds it")
+	}
+
+	if err := s.Release("1"); err != nil {
+		t.Fatal(err)
+	}
+	if !claim(t, s, "1") {
+		t.Fatal("Claim after Release was refused")
+	}
+
+	for status, again := range map[Status]bool{
+		StatusFailed:  true,
+		StatusPosted:  false,
+		StatusSkipped: false,
+		StatusDrafted: false,
+	} {
+		id := "t-" + string(status)
+		claim(t, s, id)
+		if err := s.Finish(Record{TweetID: id, Status: status}); err != nil {
+			t.Fatal(err)
+		}
+		if got := claim(t, s, id); got != again {
+			t.Errorf("Claim after %s = %v, want %v", status, got, again)
+		}
+	}
+}
+
+func TestClaimTakesOverStaleClaims(t *testing.T) {
+	s := openTest(t)
+	if err := s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(bucketProcessed), "1", Record{TweetID: "1", Status: StatusProcessing, UpdatedAt: time.Now().Add(-2 * claimTTL)})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !claim(t, s, "1") {
+		t.Fatal("stale claim was not taken over")
+	}
+}
+
+func TestFinishKeepsConversation(t *testing.T) {
+	s := openTest(t)
+	for _, rec := range []Record{
+		{TweetID: "1", ConversationID: "c", Answer: "first", ReplyID: "r1", Status: StatusPosted},
+		{TweetID: "2", ConversationID: "c", Answer: "failed", Status: StatusFailed},
+		{TweetID: "3", ConversationID: "c", Answer: "second", Status: StatusPosted},
+		{TweetID: "4", ConversationID: "other", Answer: "elsewhere", Status: StatusPosted},
+	} {
+		if err := s.Finish(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := s.Conversation("c", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Answer != "first" || got[1].Answer != "second" {
+		t.Fatalf("Conversation = %+v, want the two posted answers in order", got)
+	}
+	if rec, found, err := s.Get("1"); err != nil || !found || rec.ReplyID != "r1" || rec.UpdatedAt.IsZero() {
+		t.Fatalf("Get = %+v, %v, %v", rec, found, err)
+	}
+	if got, _ := s.Conversation("c", 1); len(got) != 1 || got[0].Answer != "second" {
+		t.Fatalf("Conversation with limit = %+v", got)
+	}
+}
@@ -0,0 +1,24 @@
+package store
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketState = []byte("state")
+
+// GetState returns a small persisted value such as a poller cursor.
+func (s *Store) GetState(key string) (string, error) {
+	var v string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v = string(tx.Bucket(bucketState).Get([]byte(key)))
+		return nil
+	})
+	return v, err
+}
+
+// PutState persists a small value under key.
+func (s *Store) PutState(key, value string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketState).Put([]byte(key), []byte(value))
+	})
+}
@@ -0,0 +1,161 @@
+// Package tracing sets up OpenTelemetry tracing for the bot, the agent and the
+// MCP servers, and carries trace context between them: through W3C
+// traceparent headers on HTTP and through TRACEPARENT/TRACESTATE env vars into
+// the agent subprocess.
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentation = "cg-mentions-bot"
+
+// Env var names used to pass trace context to a subprocess.
+const (
+	EnvTraceParent = "TRACEPARENT"
+	EnvTraceState  = "TRACESTATE"
+)
+
+// Setup installs the global propagator and tracer provider. Spans are exported
+// over OTLP/HTTP only when an endpoint is configured (OTEL_EXPORTER_OTLP_ENDPOINT
+// or OTEL_EXPORTER_OTLP_TRACES_ENDPOINT); without one, trace IDs are still
+// generated and propagated so results and logs can be correlated.
+// OTEL_SERVICE_NAME overrides service. The returned function flushes pending
+// spans; call it before exiting.
+func Setup(ctx context.Context, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if v := os.Getenv("OTEL_SERVICE_NAME"); v != "" {
+		service = v
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service)))
+	if err != nil {
+		return nil, err
+	}
+	opts := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" {
+		exp, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exp))
+	}
+	tp := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// Start starts a span with the shared tracer.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records err on span (if any) and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceID returns the trace ID of the span in ctx, or "" when there is none.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// Env returns TRACEPARENT/TRACESTATE entries for the span in ctx, to be
+// appended to a subprocess environment.
+func Env(ctx context.Context) []string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	var env []string
+	if v := carrier.Get("traceparent"); v != "" {
+		env = append(env, EnvTraceParent+"="+v)
+	}
+	if v := carrier.Get("tracestate"); v != "" {
+		env = append(env, EnvTraceState+"="+v)
+	}
+	return env
+}
+
+// FromEnv returns ctx carrying the remote span context set by Env in our parent process.
+func FromEnv(ctx context.Context) context.Context {
+	carrier := propagation.MapCarrier{}
+	if v := os.Getenv(EnvTraceParent); v != "" {
+		carrier.Set("traceparent", v)
+	}
+	if v := os.Getenv(EnvTraceState); v != "" {
+		carrier.Set("tracestate", v)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// Headers returns the propagation headers for the span in ctx.
+func Headers(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// Inject adds the propagation headers for the span in ctx to h.
+func Inject(ctx context.Context, h http.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// Handler continues the trace from the request headers and wraps each request
+// in a server span named after the method and path.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := otel.Tracer(instrumentation).Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(attribute.String("http.request.method", r.Method), attribute.String("url.path", r.URL.Path)))
+		defer span.End()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ToolMiddleware wraps every tool call of an MCP server in a span.
+func ToolMiddleware(serverName string) server.ToolHandlerMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			ctx, span := Start(ctx, "tool "+req.Params.Name,
+				attribute.String("mcp.server", serverName), attribute.String("mcp.tool", req.Params.Name))
+			res, err := next(ctx, req)
+			if err == nil && res != nil && res.IsError {
+				span.SetStatus(codes.Error, strings.TrimSpace(toolText(res)))
+			}
+			End(span, err)
+			return res, err
+		}
+	}
+}
+
+func toolText(res *mcp.CallToolResult) string {
+	var b strings.Builder
+	for _, c := range res.Content {
+		if t, ok := c.(mcp.TextContent); ok {
+			b.WriteString(t.Text)
+		}
+	}
+	return b.String()
+}
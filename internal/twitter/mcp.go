@@ -0,0 +1,32 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+
+	"cg-mentions-bot/internal/handlers"
+	mcpclient "cg-mentions-bot/internal/mcp"
+)
+
+// PostReplyTool is the xmcp tool that posts a reply under a tweet.
+const PostReplyTool = "twitter.post_reply"
+
+// NewMCPPoster returns a function that posts a reply through the xmcp server's
+// twitter.post_reply tool, for deployments where only xmcp holds X credentials.
+// It returns the tweet_id that xmcp reports, or "" when there is none.
+func NewMCPPoster(mcpURL string) func(ctx context.Context, in handlers.ReplyIn) (string, error) {
+	return func(ctx context.Context, in handlers.ReplyIn) (string, error) {
+		text, err := mcpclient.CallHTTP(ctx, mcpURL, PostReplyTool, map[string]interface{}{
+			"in_reply_to_tweet_id": in.InReplyTo,
+			"text":                 in.Text,
+		})
+		if err != nil {
+			return "", err
+		}
+		var out struct {
+			TweetID string `json:"tweet_id"`
+		}
+		_ = json.Unmarshal([]byte(text), &out)
+		return out.TweetID, nil
+	}
+}
@@ -0,0 +1,158 @@
+package twitter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cg-mentions-bot/internal/metrics"
+	"cg-mentions-bot/internal/types"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// maxMentionPages bounds how many pages one fetch walks through.
+const maxMentionPages = 10
+
+// mentionFields are the tweet.fields requested for each mention.
+const mentionFields = "author_id,conversation_id,created_at,lang,referenced_tweets,entities,public_metrics"
+
+type apiTweet struct {
+	ID               string `json:"id"`
+	Text             string `json:"text"`
+	AuthorID         string `json:"author_id"`
+	ConversationID   string `json:"conversation_id"`
+	CreatedAt        string `json:"created_at"`
+	Lang             string `json:"lang"`
+	ReferencedTweets []struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	} `json:"referenced_tweets"`
+	Entities      *types.Entities      `json:"entities"`
+	PublicMetrics *types.PublicMetrics `json:"public_metrics"`
+}
+
+type mentionsPage struct {
+	Data     []apiTweet `json:"data"`
+	Includes struct {
+		Users []struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		} `json:"users"`
+		// Tweets holds the referenced tweets, so quoted text is available.
+		Tweets []apiTweet `json:"tweets"`
+	} `json:"includes"`
+	Meta struct {
+		NewestID  string `json:"newest_id"`
+		NextToken string `json:"next_token"`
+	} `json:"meta"`
+}
+
+// NewMentionsFetcher returns a function that lists mentions of userID newer than
+// sinceID using GET /2/users/:id/mentions. It returns mentions oldest first and
+// the newest tweet ID seen (or sinceID when there is nothing new). Without a
+// sinceID only the first page is read, which is enough to seed a cursor.
+func NewMentionsFetcher(baseURL, bearer string) func(ctx context.Context, userID, sinceID string) ([]types.Mention, string, error) {
+	client := retryablehttp.NewClient()
+	client.Logger = nil
+	client.HTTPClient.Transport = metrics.XTransport("mentions", client.HTTPClient.Transport)
+
+	return func(ctx context.Context, userID, sinceID string) ([]types.Mention, string, error) {
+		newest := sinceID
+		var out []types.Mention
+		token := ""
+		for page := 0; page < maxMentionPages; page++ {
+			q := url.Values{}
+			q.Set("max_results", "100")
+			q.Set("tweet.fields", mentionFields)
+			q.Set("expansions", "author_id,referenced_tweets.id,referenced_tweets.id.author_id")
+			q.Set("user.fields", "username")
+			if sinceID != "" {
+				q.Set("since_id", sinceID)
+			}
+			if token != "" {
+				q.Set("pagination_token", token)
+			}
+			u := fmt.Sprintf("%s/users/%s/mentions?%s", baseURL, url.PathEscape(userID), q.Encode())
+
+			req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+			if err != nil {
+				return nil, sinceID, err
+			}
+			req.Header.Set("Authorization", "Bearer "+bearer)
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, sinceID, err
+			}
+			var p mentionsPage
+			err = decodeResponse(resp, &p)
+			if err != nil {
+				return nil, sinceID, err
+			}
+
+			users := make(map[string]string, len(p.Includes.Users))
+			for _, u := range p.Includes.Users {
+				users[u.ID] = u.Username
+			}
+			refs := make(map[string]apiTweet, len(p.Includes.Tweets))
+			for _, t := range p.Includes.Tweets {
+				refs[t.ID] = t
+			}
+			for _, t := range p.Data {
+				m := types.Mention{
+					TweetID:        t.ID,
+					Text:           t.Text,
+					AuthorID:       t.AuthorID,
+					AuthorUsername: users[t.AuthorID],
+					ConversationID: t.ConversationID,
+					Lang:           t.Lang,
+					Entities:       t.Entities,
+					PublicMetrics:  t.PublicMetrics,
+				}
+				if ts, err := time.Parse(time.RFC3339, t.CreatedAt); err == nil {
+					m.CreatedAt = ts.UTC()
+				}
+				for _, r := range t.ReferencedTweets {
+					ref := refs[r.ID]
+					m.ReferencedTweets = append(m.ReferencedTweets, types.ReferencedTweet{
+						Type:           r.Type,
+						ID:             r.ID,
+						Text:           ref.Text,
+						AuthorID:       ref.AuthorID,
+						AuthorUsername: users[ref.AuthorID],
+					})
+				}
+				out = append(out, m)
+			}
+			if page == 0 && p.Meta.NewestID != "" {
+				newest = p.Meta.NewestID
+			}
+			if p.Meta.NextToken == "" || sinceID == "" {
+				break
+			}
+			token = p.Meta.NextToken
+		}
+
+		// The API returns newest first; answer in the order people asked.
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+		return out, newest, nil
+	}
+}
+
+func decodeResponse(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		if len(b) > 0 {
+			return fmt.Errorf("twitter request failed: status %d: %s", resp.StatusCode, string(b))
+		}
+		return fmt.Errorf("twitter request failed: status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -7,36 +7,42 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
-	"strings"
 
 	"cg-mentions-bot/internal/handlers"
+	"cg-mentions-bot/internal/metrics"
 
 	"github.com/dghubble/oauth1"
 	"github.com/hashicorp/go-retryablehttp"
 )
 
-// NewPoster returns a function that posts a reply tweet using Twitter API v2.
+// OAuth1 holds the user-context OAuth 1.0a credentials for posting.
+type OAuth1 struct {
+	ConsumerKey    string
+	ConsumerSecret string
+	AccessToken    string
+	AccessSecret   string
+}
+
+// NewPoster returns a function that posts a reply tweet using Twitter API v2
+// and returns the new tweet's ID.
 // Auth modes:
-// - Default (OAuth2 bearer): set X_BEARER_TOKEN
-// - OAuth1: set X_AUTH_MODE=oauth1 and provide X_CONSUMER_KEY, X_CONSUMER_SECRET, X_ACCESS_TOKEN, X_ACCESS_SECRET
-func NewPoster(baseURL, bearer string) func(ctx context.Context, in handlers.ReplyIn) error {
+// - Default (OAuth2 bearer): oauth is nil and bearer is a user-context token
+// - OAuth1: oauth holds the consumer key/secret and access token/secret
+func NewPoster(baseURL, bearer string, oauth *OAuth1) func(ctx context.Context, in handlers.ReplyIn) (string, error) {
 	client := retryablehttp.NewClient()
 	client.Logger = nil
+	client.HTTPClient.Transport = metrics.XTransport("post_reply", client.HTTPClient.Transport)
 
-	useOAuth1 := strings.EqualFold(os.Getenv("X_AUTH_MODE"), "oauth1")
+	useOAuth1 := oauth != nil
 	var oauth1Client *http.Client
 	if useOAuth1 {
-		ck := os.Getenv("X_CONSUMER_KEY")
-		cs := os.Getenv("X_CONSUMER_SECRET")
-		at := os.Getenv("X_ACCESS_TOKEN")
-		as := os.Getenv("X_ACCESS_SECRET")
-		config := oauth1.NewConfig(ck, cs)
-		token := oauth1.NewToken(at, as)
+		config := oauth1.NewConfig(oauth.ConsumerKey, oauth.ConsumerSecret)
+		token := oauth1.NewToken(oauth.AccessToken, oauth.AccessSecret)
 		oauth1Client = config.Client(context.Background(), token)
+		oauth1Client.Transport = metrics.XTransport("post_reply", oauth1Client.Transport)
 	}
 
-	return func(ctx context.Context, in handlers.ReplyIn) error {
+	return func(ctx context.Context, in handlers.ReplyIn) (string, error) {
 		url := fmt.Sprintf("%s/tweets", baseURL)
 		body := map[string]any{
 			"text": in.Text,
@@ -46,51 +52,63 @@ func NewPoster(baseURL, bearer string) func(ctx context.Context, in handlers.Rep
 		}
 		payload, err := json.Marshal(body)
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		if useOAuth1 {
 			// Use raw http.Client with OAuth1 transport
 			req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 			if err != nil {
-				return err
+				return "", err
 			}
 			req.Header.Set("Content-Type", "application/json")
 			resp, err := oauth1Client.Do(req)
 			if err != nil {
-				return err
+				return "", err
 			}
 			defer resp.Body.Close()
 			if resp.StatusCode >= 300 {
 				b, _ := io.ReadAll(resp.Body)
 				if len(b) > 0 {
-					return fmt.Errorf("twitter post failed: status %d: %s", resp.StatusCode, string(b))
+					return "", fmt.Errorf("twitter post failed: status %d: %s", resp.StatusCode, string(b))
 				}
-				return fmt.Errorf("twitter post failed: status %d", resp.StatusCode)
+				return "", fmt.Errorf("twitter post failed: status %d", resp.StatusCode)
 			}
-			return nil
+			return tweetID(resp.Body), nil
 		}
 
 		// OAuth2 bearer default
 		req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 		if err != nil {
-			return err
+			return "", err
 		}
 		req.Header.Set("Authorization", "Bearer "+bearer)
 		req.Header.Set("Content-Type", "application/json")
 
 		resp, err := client.Do(req)
 		if err != nil {
-			return err
+			return "", err
 		}
 		defer resp.Body.Close()
 		if resp.StatusCode >= 300 {
 			b, _ := io.ReadAll(resp.Body)
 			if len(b) > 0 {
-				return fmt.Errorf("twitter post failed: status %d: %s", resp.StatusCode, string(b))
+				return "", fmt.Errorf("twitter post failed: status %d: %s", resp.StatusCode, string(b))
 			}
-			return fmt.Errorf("twitter post failed: status %d", resp.StatusCode)
+			return "", fmt.Errorf("twitter post failed: status %d", resp.StatusCode)
 		}
-		return nil
+		return tweetID(resp.Body), nil
+	}
+}
+
+// tweetID reads the new tweet's ID from a POST /tweets response; it is empty
+// when the body does not have one.
+func tweetID(body io.Reader) string {
+	var out struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
 	}
+	_ = json.NewDecoder(body).Decode(&out)
+	return out.Data.ID
 }
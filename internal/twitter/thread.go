@@ -0,0 +1,109 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"cg-mentions-bot/internal/handlers"
+	"cg-mentions-bot/internal/metrics"
+	"cg-mentions-bot/internal/types"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+type tweetLookup struct {
+	Data struct {
+		ID               string `json:"id"`
+		Text             string `json:"text"`
+		AuthorID         string `json:"author_id"`
+		ReferencedTweets []struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"referenced_tweets"`
+	} `json:"data"`
+	Includes struct {
+		Users []struct {
+			ID       string `json:"id"`
+			Username string `json:"username"`
+		} `json:"users"`
+	} `json:"includes"`
+}
+
+// NewThreadFetcher returns a function that walks up the replied_to chain above
+// a mention (at most depth tweets) using GET /2/tweets/:id and returns the
+// parents oldest first. The walk starts at the mention's replied_to reference,
+// so the mention itself is only looked up when the payload lacks it. If a
+// lookup fails, the parents found so far are returned with the error.
+func NewThreadFetcher(baseURL, bearer string, depth int) func(ctx context.Context, m types.Mention) ([]handlers.Turn, error) {
+	client := retryablehttp.NewClient()
+	client.Logger = nil
+	client.HTTPClient.Transport = metrics.XTransport("tweet_lookup", client.HTTPClient.Transport)
+
+	lookup := func(ctx context.Context, id string) (tweetLookup, error) {
+		q := url.Values{}
+		q.Set("tweet.fields", "author_id,referenced_tweets")
+		q.Set("expansions", "author_id")
+		q.Set("user.fields", "username")
+		u := fmt.Sprintf("%s/tweets/%s?%s", baseURL, url.PathEscape(id), q.Encode())
+		var out tweetLookup
+		req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return out, err
+		}
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		resp, err := client.Do(req)
+		if err != nil {
+			return out, err
+		}
+		return out, decodeResponse(resp, &out)
+	}
+
+	parentOf := func(t tweetLookup) string {
+		for _, r := range t.Data.ReferencedTweets {
+			if r.Type == types.RefRepliedTo {
+				return r.ID
+			}
+		}
+		return ""
+	}
+
+	return func(ctx context.Context, m types.Mention) ([]handlers.Turn, error) {
+		parent := ""
+		for _, r := range m.ReferencedTweets {
+			if r.Type == types.RefRepliedTo {
+				parent = r.ID
+			}
+		}
+		if parent == "" {
+			cur, err := lookup(ctx, m.TweetID)
+			if err != nil {
+				return nil, err
+			}
+			parent = parentOf(cur)
+		}
+
+		var turns []handlers.Turn
+		var err error
+		for parent != "" && len(turns) < depth {
+			var cur tweetLookup
+			if cur, err = lookup(ctx, parent); err != nil {
+				err = fmt.Errorf("tweet %s: %w", parent, err)
+				break
+			}
+			username := ""
+			for _, u := range cur.Includes.Users {
+				if u.ID == cur.Data.AuthorID {
+					username = u.Username
+				}
+			}
+			turns = append(turns, handlers.Turn{AuthorID: cur.Data.AuthorID, AuthorUsername: username, Text: cur.Data.Text})
+			parent = parentOf(cur)
+		}
+		for i, j := 0, len(turns)-1; i < j; i, j = i+1, j-1 {
+			turns[i], turns[j] = turns[j], turns[i]
+		}
+		return turns, err
+	}
+}
@@ -0,0 +1,76 @@
+package twitter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"cg-mentions-bot/internal/types"
+)
+
+// threadServer serves GET /tweets/:id for a thread where 4 replies to 3, 3 to
+// 2 and 2 to 1, and tweet 1 has been deleted.
+func threadServer(t *testing.T) (*httptest.Server, func() []string) {
+	tweets := map[string]string{
+		"4": `{"data":{"id":"4","text":"@bot and in EUR?","author_id":"u1","referenced_tweets":[{"type":"replied_to","id":"3"}]}}`,
+		"3": `{"data":{"id":"3","text":"BTC is $1","author_id":"bot","referenced_tweets":[{"type":"replied_to","id":"2"}]},"includes":{"users":[{"id":"bot","username":"cgbot"}]}}`,
+		"2": `{"data":{"id":"2","text":"@cgbot btc price?","author_id":"u1","referenced_tweets":[{"type":"replied_to","id":"1"}]},"includes":{"users":[{"id":"u1","username":"alice"}]}}`,
+	}
+	var mu sync.Mutex
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/tweets/")
+		mu.Lock()
+		seen = append(seen, id)
+		mu.Unlock()
+		body, ok := tweets[id]
+		if !ok {
+			http.Error(w, `{"title":"Not Found Error"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestThreadFetcherStartsAtRepliedTo(t *testing.T) {
+	srv, seen := threadServer(t)
+	fetch := NewThreadFetcher(srv.URL, "token", 2)
+	m := types.Mention{TweetID: "4", ReferencedTweets: []types.ReferencedTweet{{Type: types.RefRepliedTo, ID: "3"}}}
+
+	turns, err := fetch(context.Background(), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(seen(), ","); got != "3,2" {
+		t.Errorf("looked up %s, want 3,2", got)
+	}
+	if len(turns) != 2 || turns[0].AuthorUsername != "alice" || turns[1].Text != "BTC is $1" {
+		t.Fatalf("turns = %+v, want alice's question then our answer", turns)
+	}
+}
+
+func TestThreadFetcherReturnsPartialChain(t *testing.T) {
+	srv, seen := threadServer(t)
+	fetch := NewThreadFetcher(srv.URL, "token", 5)
+
+	// Without referenced_tweets the mention itself is looked up first.
+	turns, err := fetch(context.Background(), types.Mention{TweetID: "4"})
+	if err == nil || !strings.Contains(err.Error(), "tweet 1") {
+		t.Errorf("err = %v, want the failed lookup of tweet 1", err)
+	}
+	if got := strings.Join(seen(), ","); got != "4,3,2,1" {
+		t.Errorf("looked up %s, want 4,3,2,1", got)
+	}
+	if len(turns) != 2 || turns[0].Text != "@cgbot btc price?" || turns[1].Text != "BTC is $1" {
+		t.Fatalf("turns = %+v, want the two parents found before the failure", turns)
+	}
+}
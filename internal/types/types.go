@@ -1,13 +1,131 @@
 package types
 
-// Mention represents one mention payload item.
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Mention represents one mention payload item. Field names follow the X API v2
+// tweet object, so tweets from the API can be passed through unchanged.
 type Mention struct {
 	TweetID        string `json:"tweet_id"`
 	Text           string `json:"text"`
 	AuthorID       string `json:"author_id"`
 	AuthorUsername string `json:"author_username"`
 	ConversationID string `json:"conversation_id"`
-	CreatedAt      string `json:"created_at"`
+	// CreatedAt must be RFC 3339 when present; the zero time means unknown.
+	CreatedAt time.Time `json:"created_at"`
+	// Lang is X's BCP47 language tag for the tweet, when the sender provides it.
+	Lang             string            `json:"lang,omitempty"`
+	ReferencedTweets []ReferencedTweet `json:"referenced_tweets,omitempty"`
+	Entities         *Entities         `json:"entities,omitempty"`
+	PublicMetrics    *PublicMetrics    `json:"public_metrics,omitempty"`
+}
+
+// Reference types used in ReferencedTweet.Type.
+const (
+	RefQuoted    = "quoted"
+	RefRepliedTo = "replied_to"
+	RefRetweeted = "retweeted"
+)
+
+// ReferencedTweet is a tweet the mention quotes, replies to or retweets. Text and
+// author are optional; senders fill them from the API's expanded includes.
+type ReferencedTweet struct {
+	Type           string `json:"type"`
+	ID             string `json:"id"`
+	Text           string `json:"text,omitempty"`
+	AuthorID       string `json:"author_id,omitempty"`
+	AuthorUsername string `json:"author_username,omitempty"`
+}
+
+// Entities are the parsed parts of the tweet text, with rune offsets as X reports them.
+type Entities struct {
+	Cashtags []TagEntity  `json:"cashtags,omitempty"`
+	Hashtags []TagEntity  `json:"hashtags,omitempty"`
+	Mentions []UserEntity `json:"mentions,omitempty"`
+	URLs     []URLEntity  `json:"urls,omitempty"`
+}
+
+// TagEntity is a cashtag or hashtag without its "$" or "#".
+type TagEntity struct {
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+	Tag   string `json:"tag"`
+}
+
+// UserEntity is an @mention.
+type UserEntity struct {
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Username string `json:"username"`
+	ID       string `json:"id,omitempty"`
+}
+
+// URLEntity is a t.co link and where it points.
+type URLEntity struct {
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	URL         string `json:"url"`
+	ExpandedURL string `json:"expanded_url,omitempty"`
+	DisplayURL  string `json:"display_url,omitempty"`
+}
+
+// PublicMetrics are the tweet's engagement counts at the time it was fetched.
+type PublicMetrics struct {
+	RetweetCount    int `json:"retweet_count"`
+	ReplyCount      int `json:"reply_count"`
+	LikeCount       int `json:"like_count"`
+	QuoteCount      int `json:"quote_count"`
+	BookmarkCount   int `json:"bookmark_count,omitempty"`
+	ImpressionCount int `json:"impression_count,omitempty"`
+}
+
+// mentionJSON is Mention with created_at as a string, so it can be validated
+// with a useful error and omitted when unknown.
+type mentionJSON struct {
+	mentionAlias
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+type mentionAlias Mention
+
+// UnmarshalJSON rejects created_at values that are not RFC 3339 timestamps.
+func (m *Mention) UnmarshalJSON(b []byte) error {
+	var raw mentionJSON
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*m = Mention(raw.mentionAlias)
+	m.CreatedAt = time.Time{}
+	if raw.CreatedAt != "" {
+		t, err := time.Parse(time.RFC3339, raw.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("mention %s: created_at %q is not an RFC 3339 timestamp", raw.TweetID, raw.CreatedAt)
+		}
+		m.CreatedAt = t.UTC()
+	}
+	return nil
+}
+
+// MarshalJSON writes created_at in RFC 3339 and omits it when unknown.
+func (m Mention) MarshalJSON() ([]byte, error) {
+	raw := mentionJSON{mentionAlias: mentionAlias(m)}
+	if !m.CreatedAt.IsZero() {
+		raw.CreatedAt = m.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return json.Marshal(raw)
+}
+
+// Quoted returns the tweet m quotes, if any.
+func (m Mention) Quoted() (ReferencedTweet, bool) {
+	for _, r := range m.ReferencedTweets {
+		if r.Type == RefQuoted {
+			return r, true
+		}
+	}
+	return ReferencedTweet{}, false
 }
 
 // MentionsPayload is the full body we receive from n8n.
@@ -16,3 +134,41 @@ type MentionsPayload struct {
 	Mentions []Mention      `json:"mentions"`
 	Meta     map[string]any `json:"meta,omitempty"`
 }
+
+// AgentStep is one tool call the agent made while answering.
+type AgentStep struct {
+	Tool        string `json:"tool"`
+	Input       string `json:"input,omitempty"`
+	Observation string `json:"observation,omitempty"`
+}
+
+// MentionResult is the per-mention outcome reported by /mentions and /jobs.
+type MentionResult struct {
+	TweetID string `json:"tweet_id"`
+	Posted  bool   `json:"posted"`
+	// ReplyID is the posted reply's tweet ID, when the poster reported it.
+	ReplyID  string `json:"reply_id,omitempty"`
+	Category string `json:"category,omitempty"`
+	Lang     string `json:"lang,omitempty"`
+	// Coins are the CoinGecko IDs resolved from the mention and hinted to the agent.
+	Coins []string `json:"coins,omitempty"`
+	// Stale is the staleness mode ("stamp" or "historical") applied to a delayed mention.
+	Stale   string `json:"stale,omitempty"`
+	Skipped string `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+	// Failure is the stage that produced Error: store, agent, ask or reply.
+	Failure string `json:"failure,omitempty"`
+	// DryRun and PendingApproval results carry the drafted reply instead of posting it.
+	DryRun          bool     `json:"dry_run,omitempty"`
+	PendingApproval bool     `json:"pending_approval,omitempty"`
+	Draft           string   `json:"draft,omitempty"`
+	Tools           []string `json:"tools,omitempty"`
+	// TraceID identifies the mention's trace across the bot, agent and MCP servers.
+	TraceID string `json:"trace_id,omitempty"`
+	// Steps are the agent's intermediate steps; only drafts report them.
+	Steps []AgentStep `json:"steps,omitempty"`
+	// Question is the prompt sent to the agent or MCP tool and Answer what came
+	// back; both are kept for the audit log rather than returned.
+	Question string `json:"-"`
+	Answer   string `json:"-"`
+}
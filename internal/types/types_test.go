@@ -0,0 +1,85 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMentionCreatedAt(t *testing.T) {
+	tests := []struct {
+		name, body string
+		want       time.Time
+		wantErr    string
+	}{
+		{"utc", `{"tweet_id":"1","created_at":"2025-08-20T10:00:00Z"}`, time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC), ""},
+		{"offset", `{"tweet_id":"1","created_at":"2025-08-20T13:00:00+03:00"}`, time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC), ""},
+		{"fractional", `{"tweet_id":"1","created_at":"2025-08-20T10:00:00.123Z"}`, time.Date(2025, 8, 20, 10, 0, 0, 123e6, time.UTC), ""},
+		{"missing", `{"tweet_id":"1"}`, time.Time{}, ""},
+		{"empty", `{"tweet_id":"1","created_at":""}`, time.Time{}, ""},
+		{"date only", `{"tweet_id":"1","created_at":"2025-08-20"}`, time.Time{}, `mention 1: created_at "2025-08-20"`},
+		{"twitter v1 format", `{"tweet_id":"2","created_at":"Wed Aug 20 10:00:00 +0000 2025"}`, time.Time{}, "mention 2: created_at"},
+		{"not a string", `{"tweet_id":"1","created_at":1755684000}`, time.Time{}, "cannot unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m Mention
+			err := json.Unmarshal([]byte(tt.body), &m)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Unmarshal = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !m.CreatedAt.Equal(tt.want) || m.CreatedAt.Location() != time.UTC {
+				t.Fatalf("CreatedAt = %v, want %v in UTC", m.CreatedAt, tt.want)
+			}
+			if m.TweetID != "1" {
+				t.Fatalf("TweetID = %q", m.TweetID)
+			}
+		})
+	}
+}
+
+func TestMentionRoundTrip(t *testing.T) {
+	in := Mention{
+		TweetID:          "1",
+		Text:             "$BTC?",
+		CreatedAt:        time.Date(2025, 8, 20, 10, 0, 0, 0, time.UTC),
+		ReferencedTweets: []ReferencedTweet{{Type: RefQuoted, ID: "0", Text: "quoted"}},
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"created_at":"2025-08-20T10:00:00Z"`) {
+		t.Fatalf("Marshal = %s", b)
+	}
+	var out Mention
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !out.CreatedAt.Equal(in.CreatedAt) || out.Text != in.Text {
+		t.Fatalf("round trip = %+v", out)
+	}
+	if q, ok := out.Quoted(); !ok || q.Text != "quoted" {
+		t.Fatalf("Quoted = %+v, %v", q, ok)
+	}
+
+	b, _ = json.Marshal(Mention{TweetID: "2"})
+	if strings.Contains(string(b), "created_at") {
+		t.Fatalf("unknown created_at was written: %s", b)
+	}
+}
+
+func TestMentionsPayloadRejectsBadDates(t *testing.T) {
+	var p MentionsPayload
+	err := json.Unmarshal([]byte(`{"count":2,"mentions":[{"tweet_id":"1"},{"tweet_id":"2","created_at":"yesterday"}]}`), &p)
+	if err == nil || !strings.Contains(err.Error(), "mention 2") {
+		t.Fatalf("Unmarshal = %v, want an error naming mention 2", err)
+	}
+}
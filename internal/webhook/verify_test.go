@@ -0,0 +1,107 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(secret string, ts int64, nonce string, body []byte) http.Header {
+	msg := strconv.FormatInt(ts, 10) + "."
+	if nonce != "" {
+		msg += nonce + "."
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(msg))
+	mac.Write(body)
+	h := http.Header{}
+	h.Set(HeaderSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	h.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	if nonce != "" {
+		h.Set(HeaderNonce, nonce)
+	}
+	return h
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"count":1}`)
+	now := time.Now().Unix()
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		want   error
+	}{
+		{"valid", sign("new", now, "", body), body, nil},
+		{"old secret during rotation", sign("old", now, "", body), body, nil},
+		{"valid with nonce", sign("new", now, "n1", body), body, nil},
+		{"unknown secret", sign("other", now, "", body), body, ErrBadSignature},
+		{"body changed", sign("new", now, "", body), []byte(`{"count":2}`), ErrBadSignature},
+		{"too old", sign("new", now-600, "", body), body, ErrBadTimestamp},
+		{"from the future", sign("new", now+600, "", body), body, ErrBadTimestamp},
+		{"no headers", http.Header{}, body, ErrMissingSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerifier([]string{" new ", "old", ""}, 5*time.Minute)
+			if err := v.Verify(tt.header, tt.body); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsReplays(t *testing.T) {
+	v := NewVerifier([]string{"s"}, 5*time.Minute)
+	body := []byte(`{}`)
+	now := time.Now().Unix()
+
+	h := sign("s", now, "", body)
+	if err := v.Verify(h, body); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(h, body); !errors.Is(err, ErrReplay) {
+		t.Fatalf("second Verify = %v, want ErrReplay", err)
+	}
+
+	// A nonce identifies the request, even when it is re-signed.
+	if err := v.Verify(sign("s", now, "abc", body), body); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(sign("s", now-1, "abc", body), body); !errors.Is(err, ErrReplay) {
+		t.Fatalf("reused nonce = %v, want ErrReplay", err)
+	}
+}
+
+func TestVerifyX(t *testing.T) {
+	body := []byte(`{"tweet_create_events":[]}`)
+	header := "sha256=" + xMAC("consumer", body)
+	if err := VerifyX("consumer", header, body); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyX("other", header, body); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("VerifyX with wrong secret = %v", err)
+	}
+	if err := VerifyX("consumer", header, []byte(`{"tweet_create_events":[{}]}`)); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("VerifyX with tampered body = %v", err)
+	}
+	if err := VerifyX("consumer", "sha256=bm90IGEgc2lnbmF0dXJl", body); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("VerifyX with forged header = %v", err)
+	}
+	if err := VerifyX("consumer", "", body); !errors.Is(err, ErrMissingSignature) {
+		t.Fatalf("VerifyX without header = %v", err)
+	}
+}
+
+func TestCRCResponseToken(t *testing.T) {
+	// base64(HMAC-SHA256("secret", "challenge")), computed independently.
+	const want = "sha256=oeUF6Wxqoezggrue+wbIDxKRPSF6esKwizR2MHh9HaA="
+	if got := CRCResponseToken("secret", "challenge"); got != want {
+		t.Fatalf("CRCResponseToken = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// HeaderXSignature carries X's signature on Account Activity API deliveries.
+const HeaderXSignature = "X-Twitter-Webhooks-Signature"
+
+// CRCResponseToken answers X's challenge-response check for crcToken.
+func CRCResponseToken(consumerSecret, crcToken string) string {
+	return "sha256=" + xMAC(consumerSecret, []byte(crcToken))
+}
+
+// VerifyX checks the X-Twitter-Webhooks-Signature header value against body.
+func VerifyX(consumerSecret, header string, body []byte) error {
+	if header == "" {
+		return ErrMissingSignature
+	}
+	want := "sha256=" + xMAC(consumerSecret, body)
+	if !hmac.Equal([]byte(strings.TrimSpace(header)), []byte(want)) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+func xMAC(secret string, msg []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(msg)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}